@@ -0,0 +1,243 @@
+package sglog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// AdminHandler returns an http.Handler to inspect and change the log levels
+// of the backend and the registered vmodules.
+//
+// GET requests report the backend's default log level, source file vmodule
+// spec, registered vmodules with their log levels and the active log file
+// paths. Response is plain text, or JSON with the format=json query parameter.
+//
+// POST requests change the log levels using the following form values. All
+// values are validated before any change is made.
+//
+//	level        backend's default log level, as accepted by ParseLevel
+//	vmodule      vmodule spec applied with ApplyVModuleSpec
+//	filevmodule  source file vmodule spec set with Backend.SetFileVModule
+//	duration     if set, changes are reverted after this duration, like 10m
+//
+// A POST without a duration cancels any pending revert, while a POST with a
+// duration reverts to the levels before the first pending change.
+func AdminHandler(backend *Backend) http.Handler {
+	return &adminHandler{backend: backend}
+}
+
+type adminHandler struct {
+	backend *Backend
+
+	mu sync.Mutex
+
+	// revert holds the saved log levels and a timer to restore them, if a
+	// change with a duration is pending.
+	revert      *adminState
+	revertTimer *time.Timer
+	revertAt    time.Time
+}
+
+// adminState holds the log levels that can be changed by the admin handler.
+// Vmodule levels are saved by their exact names, because module names can
+// have characters that are special in the vmodule specs.
+type adminState struct {
+	level       slog.Level
+	vmodules    map[string]slog.Level
+	fileVModule string
+}
+
+// adminStatus is the response for the admin handler requests.
+type adminStatus struct {
+	Level       string           `json:"level"`
+	FileVModule string           `json:"filevmodule"`
+	RevertAt    *time.Time       `json:"revert_at,omitempty"`
+	VModules    []adminVModule   `json:"vmodules"`
+	Files       []adminFileEntry `json:"files"`
+}
+
+type adminVModule struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+type adminFileEntry struct {
+	Level string `json:"level"`
+	Path  string `json:"path"`
+}
+
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPost:
+		if err := h.update(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.writeStatus(w, r.FormValue("format") == "json")
+}
+
+func (h *adminHandler) update(r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	var level *slog.Level
+	if s := r.PostForm.Get("level"); s != "" {
+		l, err := ParseLevel(s)
+		if err != nil {
+			return fmt.Errorf("invalid level: %w", err)
+		}
+		level = &l
+	}
+	vmodule, hasVModule := r.PostForm.Get("vmodule"), r.PostForm.Has("vmodule")
+	if _, err := parseVModuleSpec(vmodule); err != nil {
+		return err
+	}
+	fileVModule, hasFileVModule := r.PostForm.Get("filevmodule"), r.PostForm.Has("filevmodule")
+	if _, err := parseFileVModule(fileVModule); err != nil {
+		return err
+	}
+	var duration time.Duration
+	if s := r.PostForm.Get("duration"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		duration = d
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	saved := h.revert
+	if h.revertTimer != nil {
+		h.revertTimer.Stop()
+		h.revert, h.revertTimer, h.revertAt = nil, nil, time.Time{}
+	}
+	if saved == nil {
+		saved = h.save()
+	}
+
+	if level != nil {
+		h.backend.SetLevel(*level)
+	}
+	if hasVModule {
+		if err := ApplyVModuleSpec(vmodule); err != nil {
+			return err
+		}
+	}
+	if hasFileVModule {
+		if err := h.backend.SetFileVModule(fileVModule); err != nil {
+			return err
+		}
+	}
+
+	if duration > 0 {
+		h.revert, h.revertAt = saved, time.Now().Add(duration)
+		var timer *time.Timer
+		timer = time.AfterFunc(duration, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			// Ignore the timer if it was replaced by a newer change.
+			if h.revertTimer != timer {
+				return
+			}
+			h.restore(h.revert)
+			h.revert, h.revertTimer, h.revertAt = nil, nil, time.Time{}
+		})
+		h.revertTimer = timer
+	}
+	return nil
+}
+
+func (h *adminHandler) save() *adminState {
+	s := &adminState{
+		level:       h.backend.Level(),
+		vmodules:    make(map[string]slog.Level),
+		fileVModule: h.backend.FileVModule(),
+	}
+	for _, info := range VModules() {
+		s.vmodules[info.Name] = info.Level
+	}
+	return s
+}
+
+// restore restores the saved log levels. Vmodules registered after the levels
+// were saved keep their levels. Errors are reported with the backend's error
+// handler, because there is no request to report them to.
+func (h *adminHandler) restore(s *adminState) {
+	h.backend.SetLevel(s.level)
+	for name, level := range s.vmodules {
+		a, ok := LookupVModule(name)
+		if !ok || !SetVModuleLevel(a, level) {
+			h.backend.reportError(ErrorRevert, nil, "", fmt.Errorf("could not revert the level of vmodule %q", name))
+		}
+	}
+	if err := h.backend.SetFileVModule(s.fileVModule); err != nil {
+		h.backend.reportError(ErrorRevert, nil, "", fmt.Errorf("could not revert source file vmodule levels: %w", err))
+	}
+}
+
+func (h *adminHandler) status() *adminStatus {
+	s := &adminStatus{
+		Level:       h.backend.Level().String(),
+		FileVModule: h.backend.FileVModule(),
+	}
+	for _, info := range VModules() {
+		s.VModules = append(s.VModules, adminVModule{Name: info.Name, Level: info.Level.String()})
+	}
+	files := h.backend.LogFiles()
+	for _, l := range slices.Sorted(maps.Keys(files)) {
+		s.Files = append(s.Files, adminFileEntry{Level: levelName(l), Path: files[l]})
+	}
+
+	h.mu.Lock()
+	if h.revertTimer != nil {
+		at := h.revertAt
+		s.RevertAt = &at
+	}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *adminHandler) writeStatus(w http.ResponseWriter, asJSON bool) {
+	s := h.status()
+	if asJSON {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(s)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "level:\t%s\n", s.Level)
+	fmt.Fprintf(tw, "filevmodule:\t%s\n", s.FileVModule)
+	if s.RevertAt != nil {
+		fmt.Fprintf(tw, "revert at:\t%s\n", s.RevertAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(tw, "\nvmodules:\n")
+	for _, m := range s.VModules {
+		fmt.Fprintf(tw, "  %s\t%s\n", m.Name, m.Level)
+	}
+	fmt.Fprintf(tw, "\nfiles:\n")
+	for _, f := range s.Files {
+		fmt.Fprintf(tw, "  %s\t%s\n", f.Level, f.Path)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,128 @@
+package sglog
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminHandler(t *testing.T) {
+	backend := NewBackend(&Options{
+		Name:    "admin",
+		LogDirs: []string{t.TempDir()},
+	})
+	defer backend.Close()
+	slog.New(backend.Handler()).Info("info message")
+
+	disk := VModule("admin-disk", slog.LevelInfo)
+
+	server := httptest.NewServer(AdminHandler(backend))
+	defer server.Close()
+
+	get := func() *adminStatus {
+		resp, err := http.Get(server.URL + "?format=json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		s := new(adminStatus)
+		if err := json.NewDecoder(resp.Body).Decode(s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	post := func(values url.Values) int {
+		resp, err := http.PostForm(server.URL, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode
+	}
+
+	s := get()
+	if s.Level != "INFO" || len(s.Files) != 1 || s.Files[0].Level != "INFO" {
+		t.Fatalf("unexpected status %+v", s)
+	}
+
+	if code := post(url.Values{"level": {"debug"}, "vmodule": {"admin-*=error"}, "filevmodule": {"server=2"}}); code != http.StatusOK {
+		t.Fatalf("unexpected status code %d", code)
+	}
+	if level, _ := VModuleLevel(disk); level != slog.LevelError || backend.Level() != slog.LevelDebug || backend.FileVModule() != "server=2" {
+		t.Fatalf("log levels are not changed")
+	}
+
+	if code := post(url.Values{"level": {"bad"}, "vmodule": {"admin-*=info"}}); code != http.StatusBadRequest {
+		t.Fatalf("invalid level must fail with bad request, got %d", code)
+	}
+	if level, _ := VModuleLevel(disk); level != slog.LevelError {
+		t.Fatalf("invalid request must not change any levels")
+	}
+
+	if code := post(url.Values{"level": {"warn"}, "vmodule": {"admin-disk=debug"}, "duration": {"100ms"}}); code != http.StatusOK {
+		t.Fatalf("unexpected status code %d", code)
+	}
+	if s := get(); s.Level != "WARN" || s.RevertAt == nil {
+		t.Fatalf("unexpected status %+v", s)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if level, _ := VModuleLevel(disk); level != slog.LevelError || backend.Level() != slog.LevelDebug {
+		t.Fatalf("log levels are not reverted")
+	}
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	text, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(text), "admin-disk") || !strings.Contains(string(text), "level:") {
+		t.Fatalf("unexpected text status %q", text)
+	}
+}
+
+func TestAdminRevertSpecialNames(t *testing.T) {
+	var errs []*LogError
+	backend := NewBackend(&Options{
+		Name:         "admin",
+		LogDirs:      []string{t.TempDir()},
+		ErrorHandler: func(err *LogError) { errs = append(errs, err) },
+	})
+	defer backend.Close()
+
+	// Names with glob characters, commas and equal signs cannot be restored
+	// with a vmodule spec.
+	glob := VModule("admin-special-*", slog.LevelInfo)
+	other := VModule("admin-special-other", slog.LevelWarn)
+	comma := VModule("admin-special-a,b=c", slog.LevelError)
+	// Levels are set again, because the registry is shared by repeated runs.
+	SetVModuleLevel(glob, slog.LevelInfo)
+	SetVModuleLevel(other, slog.LevelWarn)
+	SetVModuleLevel(comma, slog.LevelError)
+
+	h := AdminHandler(backend).(*adminHandler)
+	saved := h.save()
+	if _, err := SetVModules("admin-special-*", slog.LevelDebug); err != nil {
+		t.Fatal(err)
+	}
+	h.restore(saved)
+
+	for _, tc := range []struct {
+		attr slog.Attr
+		want slog.Level
+	}{{glob, slog.LevelInfo}, {other, slog.LevelWarn}, {comma, slog.LevelError}} {
+		if level, _ := VModuleLevel(tc.attr); level != tc.want {
+			t.Errorf("vmodule %v: want level %v, got %v", tc.attr.Value, tc.want, level)
+		}
+	}
+	if len(errs) != 0 {
+		t.Errorf("unexpected revert errors %v", errs)
+	}
+}
@@ -1,10 +1,12 @@
 package sglog
 
 import (
-	"fmt"
+	"bytes"
 	"log/slog"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Backend struct {
@@ -17,6 +19,67 @@ type Backend struct {
 	fileMap map[slog.Level]*levelFile
 
 	currentLevel slog.LevelVar
+
+	// fileVModule holds the source file based vmodule rules, if any.
+	fileVModule atomic.Pointer[fileVModule]
+
+	// samplingSites holds the sampling state for log statements.
+	samplingSites sync.Map // map[samplingKey]*samplingSite
+
+	// queue holds the log messages for the background writer goroutine when
+	// asynchronous writes are enabled.
+	queue chan *logEntry
+
+	// qmu protects the closed flag and the queue channel from being closed
+	// while log messages are being sent into it.
+	qmu sync.RWMutex
+
+	closed bool
+
+	stats backendStats
+
+	// fileNameTemplate holds the literal text and fields of the log file name
+	// template.
+	fileNameTemplate []string
+
+	// recent holds the recent log ring buffers, if enabled.
+	recent *recentLogs
+
+	// disk holds the result of the last free disk space check, if enabled.
+	// diskMu serializes the checks, so that the state changes are reported
+	// exactly once.
+	disk   atomic.Pointer[diskState]
+	diskMu sync.Mutex
+
+	// done is closed when the backend is closed, to stop the background
+	// goroutines.
+	done chan struct{}
+
+	wg sync.WaitGroup
+}
+
+// logEntry holds a formatted log message and the range of levels it must be
+// written to.
+type logEntry struct {
+	minLevel, maxLevel slog.Level
+
+	msg []byte
+
+	// pc and time are the program counter and time of the log record.
+	pc   uintptr
+	time time.Time
+
+	// vmodule is the name of the log record's vmodule, if any.
+	vmodule string
+
+	// dedupKey if non-empty identifies identical log records when repeated log
+	// messages are collapsed.
+	dedupKey string
+
+	// flushed if non-nil is closed by the background writer goroutine when all
+	// log entries queued before this entry are written. Entries with non-nil
+	// flushed channel are markers and have no log message.
+	flushed chan struct{}
 }
 
 // NewBackend creates a slog backend.
@@ -25,19 +88,70 @@ func NewBackend(opts *Options) *Backend {
 	v := &Backend{
 		opts:    opts,
 		fileMap: make(map[slog.Level]*levelFile),
+		done:    make(chan struct{}),
 	}
 	v.handler = v.newHandler(opts)
 
-	levels := []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+	v.setFileNameTemplate()
+	v.checkRedactRules()
+	if opts.RecentLogSize > 0 {
+		v.recent = newRecentLogs(opts.RecentLogSize)
+	}
+
+	levels := []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, LevelFatal}
 	for _, l := range levels {
 		v.fileMap[l] = v.newLevelFile(l)
 	}
+	v.removeOldFiles(time.Now())
+	v.checkDiskSpace()
+
+	if opts.LogQueueSize > 0 {
+		v.queue = make(chan *logEntry, opts.LogQueueSize)
+		v.wg.Add(1)
+		go v.writeLoop()
+	}
+	if opts.LogDedupTimeout > 0 {
+		v.wg.Add(1)
+		go v.flushRepeatsLoop()
+	}
+	if opts.LogDirMinFreeSpace > 0 {
+		v.wg.Add(1)
+		go v.diskCheckLoop()
+	}
 	return v
 }
 
 // Close flushes the logs and waits for the background goroutine to finish.
+// All queued log messages are written, and log files are synced and closed.
+// Log messages after Close are discarded.
 func (v *Backend) Close() {
-	return
+	v.qmu.Lock()
+	if v.closed {
+		v.qmu.Unlock()
+		return
+	}
+	v.closed = true
+	if v.queue != nil {
+		close(v.queue)
+	}
+	close(v.done)
+	v.qmu.Unlock()
+
+	v.wg.Wait()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, f := range v.fileMap {
+		f.flushRepeats()
+		if f.file == nil {
+			continue
+		}
+		fpath := f.file.Name()
+		if err := f.Close(); err != nil {
+			v.reportError(ErrorClose, f, fpath, err)
+		}
+	}
 }
 
 // Handler returns slog.Handler for the log backend.
@@ -52,7 +166,29 @@ func (v *Backend) SetLevel(level slog.Level) slog.Level {
 	return last
 }
 
+// Level returns the default log level.
+func (v *Backend) Level() slog.Level {
+	return v.currentLevel.Level()
+}
+
+// LogFiles returns the paths of the currently open log files by their levels.
+func (v *Backend) LogFiles() map[slog.Level]string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	files := make(map[slog.Level]string)
+	for l, f := range v.fileMap {
+		if f.file != nil {
+			files[l] = f.file.Name()
+		}
+	}
+	return files
+}
+
 func normalize(v slog.Level) slog.Level {
+	if v >= LevelFatal {
+		return LevelFatal
+	}
 	if v >= slog.LevelError {
 		return slog.LevelError
 	}
@@ -68,21 +204,158 @@ func normalize(v slog.Level) slog.Level {
 	return slog.LevelDebug
 }
 
-func (v *Backend) emit(minLevel, maxLevel slog.Level, msg []byte) error {
+// emit writes the log entry to all log files in its [minLevel, maxLevel]
+// range. When asynchronous writes are enabled, entry is queued for the
+// background goroutine and write errors are not reported to the caller, except
+// for the FATAL log messages.
+func (v *Backend) emit(e *logEntry) error {
+	v.qmu.RLock()
+	defer v.qmu.RUnlock()
+
+	if v.closed {
+		return os.ErrClosed
+	}
+
+	// FATAL log messages are written synchronously, so they are never dropped
+	// by the queue policies.
+	if v.queue == nil || e.maxLevel >= LevelFatal {
+		return v.write(e)
+	}
+
+	// Message is backed by a pooled buffer, so it must be copied.
+	e.msg = bytes.Clone(e.msg)
+	v.enqueue(e)
+	return nil
+}
+
+// enqueue adds a log entry to the asynchronous write queue as per the
+// configured queue policy. Caller must hold the qmu read lock.
+func (v *Backend) enqueue(e *logEntry) {
+	select {
+	case v.queue <- e:
+		return
+	default:
+	}
+
+	switch v.opts.LogQueuePolicy {
+	case QueueDropNewest:
+		v.stats.dropped.Add(1)
+		return
+
+	case QueueDropOldest:
+		for {
+			select {
+			case old := <-v.queue:
+				if old.flushed != nil {
+					// Do not leave a Flush caller waiting forever.
+					close(old.flushed)
+				} else {
+					v.stats.dropped.Add(1)
+				}
+			default:
+			}
+			select {
+			case v.queue <- e:
+				return
+			default:
+			}
+		}
+
+	default:
+		v.queue <- e
+	}
+}
+
+// writeLoop writes the queued log messages till the queue is closed.
+func (v *Backend) writeLoop() {
+	defer v.wg.Done()
+
+	for e := range v.queue {
+		if e.flushed != nil {
+			close(e.flushed)
+			continue
+		}
+		v.write(e)
+	}
+}
+
+// Flush writes all queued log messages and syncs the log files. Flush is never
+// dropped by the queue policies, but with QueueDropOldest the log messages
+// queued before it can be dropped, in which case Flush returns without them.
+func (v *Backend) Flush() error {
+	v.qmu.RLock()
+	if v.closed {
+		v.qmu.RUnlock()
+		return os.ErrClosed
+	}
+	if v.queue != nil {
+		e := &logEntry{flushed: make(chan struct{})}
+		v.queue <- e
+		v.qmu.RUnlock()
+		<-e.flushed
+	} else {
+		v.qmu.RUnlock()
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var firstErr error
+	for _, f := range v.fileMap {
+		if err := f.flushRepeats(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := f.Sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// toStderr returns true if log messages at the input level must be written to
+// the standard error.
+func (v *Backend) toStderr(level slog.Level) bool {
+	if v.opts.LogToStderr || v.opts.AlsoLogToStderr {
+		return true
+	}
+	return v.opts.StderrThreshold != nil && level >= v.opts.StderrThreshold.Level()
+}
+
+func (v *Backend) write(e *logEntry) error {
+	v.addRecent(e)
+
 	v.mu.Lock()
+	if v.toStderr(e.maxLevel) {
+		os.Stderr.Write(e.msg)
+	}
+	if v.opts.LogToStderr {
+		v.mu.Unlock()
+		return nil
+	}
+	minLevel := v.lowDiskMinLevel(e.minLevel)
+	if minLevel > e.maxLevel {
+		v.mu.Unlock()
+		v.stats.lowDiskDropped.Add(1)
+		return nil
+	}
+
 	var firstErr error
 	for l, f := range v.fileMap {
-		if l < minLevel || l > maxLevel {
+		if l < minLevel || l > e.maxLevel {
 			continue
 		}
-		if _, err := f.Write(msg); err != nil && firstErr == nil {
-			firstErr = err
+		if err := f.writeEntry(e); err != nil {
+			f.stats.writeErrors.Add(1)
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
 	v.mu.Unlock()
 
+	// Errors are already reported by the level files.
 	if firstErr != nil {
-		fmt.Fprintf(os.Stderr, "could not emit log message for levels %d-%d: %v\n", minLevel, maxLevel, firstErr)
+		v.stats.writeErrors.Add(1)
 	}
 	return firstErr
 }
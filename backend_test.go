@@ -0,0 +1,226 @@
+package sglog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsyncWrites(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:         "async",
+		LogDirs:      []string{dir},
+		LogQueueSize: 16,
+	})
+
+	logger := slog.New(backend.Handler())
+	for i := 0; i < 1000; i++ {
+		logger.Info("info message", "iteration", i)
+	}
+	backend.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "async.INFO"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("info message")); n != 1000 {
+		t.Fatalf("want 1000 messages, got %d", n)
+	}
+
+	// Messages after Close must be discarded.
+	logger.Info("message after close")
+	if err := backend.handler.Handle(context.Background(), slog.Record{}); err == nil {
+		t.Fatalf("want error after close")
+	}
+}
+
+func TestAsyncDropNewest(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:           "async",
+		LogDirs:        []string{dir},
+		LogQueueSize:   1,
+		LogQueuePolicy: QueueDropNewest,
+	})
+
+	logger := slog.New(backend.Handler())
+	for i := 0; i < 1000; i++ {
+		logger.Info("info message", "iteration", i)
+	}
+	backend.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "async.INFO"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("info message")); n == 0 || n > 1000 {
+		t.Fatalf("unexpected number of messages %d", n)
+	}
+}
+
+// blockWriter blocks the background writer goroutine after it takes the next
+// log message from the queue, till the returned function is called.
+func blockWriter(t *testing.T, backend *Backend, logger *slog.Logger) func() {
+	t.Helper()
+	backend.mu.Lock()
+	logger.Info("blocked message")
+	waitQueueLen(t, backend, 0)
+	return backend.mu.Unlock
+}
+
+// waitQueueLen waits till the asynchronous write queue has n entries.
+func waitQueueLen(t *testing.T, backend *Backend, n int) {
+	t.Helper()
+	for i := 0; len(backend.queue) != n; i++ {
+		if i == 1000 {
+			t.Fatalf("want %d queued entries, got %d", n, len(backend.queue))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAsyncDropOldest(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:           "async",
+		LogDirs:        []string{dir},
+		LogQueueSize:   4,
+		LogQueuePolicy: QueueDropOldest,
+	})
+	logger := slog.New(backend.Handler())
+
+	unblock := blockWriter(t, backend, logger)
+	for i := 0; i < 10; i++ {
+		logger.Info("info message", "iteration", i)
+	}
+	if n := backend.Stats().Dropped; n != 6 {
+		t.Errorf("want 6 dropped messages, got %d", n)
+	}
+	unblock()
+	backend.Close()
+
+	var iterations []string
+	for _, line := range readLines(t, filepath.Join(dir, "async.INFO")) {
+		if _, it, ok := strings.Cut(line, "iteration="); ok {
+			iterations = append(iterations, it)
+		}
+	}
+	if want := "6,7,8,9"; strings.Join(iterations, ",") != want {
+		t.Fatalf("want iterations %s, got %q", want, iterations)
+	}
+}
+
+func TestAsyncFlushMarker(t *testing.T) {
+	for _, policy := range []QueuePolicy{QueueDropNewest, QueueDropOldest} {
+		dir := t.TempDir()
+		backend := NewBackend(&Options{
+			Name:           "async",
+			LogDirs:        []string{dir},
+			LogQueueSize:   2,
+			LogQueuePolicy: policy,
+		})
+		logger := slog.New(backend.Handler())
+
+		unblock := blockWriter(t, backend, logger)
+		flushed := make(chan error)
+		go func() { flushed <- backend.Flush() }()
+		waitQueueLen(t, backend, 1)
+
+		// Flush markers are never dropped as log messages. With QueueDropOldest
+		// they can be evicted, in which case Flush returns without waiting for
+		// the dropped log messages queued before them.
+		logger.Info("first message")
+		logger.Info("second message")
+		logger.Info("third message")
+		wantDropped := uint64(2)
+		if policy == QueueDropOldest {
+			wantDropped = 1
+		}
+		if n := backend.Stats().Dropped; n != wantDropped {
+			t.Errorf("%v: want %d dropped messages, got %d", policy, wantDropped, n)
+		}
+		unblock()
+		if err := <-flushed; err != nil {
+			t.Fatal(err)
+		}
+		backend.Close()
+
+		data, err := os.ReadFile(filepath.Join(dir, "async.INFO"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"blocked message", "first message"}
+		if policy == QueueDropOldest {
+			want = []string{"blocked message", "second message", "third message"}
+		}
+		if n := bytes.Count(data, []byte("\n")); n != len(want) {
+			t.Errorf("%v: want %d log lines, got %q", policy, len(want), data)
+		}
+		for _, msg := range want {
+			if !bytes.Contains(data, []byte(msg)) {
+				t.Errorf("%v: want %q in the log file, got %q", policy, msg, data)
+			}
+		}
+	}
+}
+
+func TestStderrModes(t *testing.T) {
+	testcases := []struct {
+		opts       Options
+		wantStderr []string
+		wantFiles  bool
+	}{
+		{Options{LogToStderr: true}, []string{"info message", "error message"}, false},
+		{Options{AlsoLogToStderr: true}, []string{"info message", "error message"}, true},
+		{Options{StderrThreshold: slog.LevelError}, []string{"error message"}, true},
+		{Options{}, nil, true},
+	}
+
+	for i, tc := range testcases {
+		dir := t.TempDir()
+		stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved := os.Stderr
+		os.Stderr = stderr
+
+		opts := tc.opts
+		opts.Name, opts.LogDirs = "stderr", []string{dir}
+		backend := NewBackend(&opts)
+		logger := slog.New(backend.Handler())
+		logger.Info("info message")
+		logger.Error("error message")
+		backend.Close()
+
+		os.Stderr = saved
+		stderr.Close()
+
+		data, err := os.ReadFile(stderr.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := bytes.Count(data, []byte("\n")); n != len(tc.wantStderr) {
+			t.Errorf("%d: want %d lines on stderr, got %q", i, len(tc.wantStderr), data)
+		}
+		for _, msg := range tc.wantStderr {
+			if !bytes.Contains(data, []byte(msg)) {
+				t.Errorf("%d: stderr has no %q", i, msg)
+			}
+		}
+
+		fdata, err := os.ReadFile(filepath.Join(dir, "stderr.ERROR"))
+		if tc.wantFiles != (err == nil) {
+			t.Errorf("%d: want log files %t, got error %v", i, tc.wantFiles, err)
+		}
+		if err == nil && len(data) > 0 && !bytes.HasSuffix(data, fdata) {
+			t.Errorf("%d: stderr lines %q do not end with log file line %q", i, data, fdata)
+		}
+	}
+}
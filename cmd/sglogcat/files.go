@@ -0,0 +1,164 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/visvasity/sglog"
+)
+
+// logFile describes a log file name in the sglog naming scheme.
+type logFile struct {
+	path string
+
+	name, host, user, level string
+
+	time time.Time
+	pid  int
+
+	compressed bool
+}
+
+// open opens the log file for reading, decompressing it when necessary.
+func (v *logFile) open() (io.ReadCloser, error) {
+	fp, err := os.Open(v.path)
+	if err != nil {
+		return nil, err
+	}
+	if !v.compressed {
+		return fp, nil
+	}
+	zr, err := gzip.NewReader(fp)
+	if err != nil {
+		fp.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: zr, file: fp}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (v *gzipFile) Close() error {
+	v.Reader.Close()
+	return v.file.Close()
+}
+
+// selector selects the log files by program name and level.
+type selector struct {
+	name  string
+	level string
+
+	// names parses the log file names in the -log_file_name template.
+	names *sglog.FileNameParser
+}
+
+// newSelector returns a selector for the log files with the input program
+// name and level names, which are created with the input log file name
+// template.
+func newSelector(name, level, tmpl string) (*selector, error) {
+	names, err := sglog.NewFileNameParser(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return &selector{name: name, level: level, names: names}, nil
+}
+
+func (s *selector) match(v *logFile) bool {
+	return (s.name == "" || s.name == v.name) && s.level == v.level
+}
+
+// parseFileName parses a log file path with the log file name template and an
+// optional .gz suffix for the compressed log files.
+func (s *selector) parseFileName(fpath string) (*logFile, error) {
+	v := &logFile{path: fpath}
+	base, compressed := strings.CutSuffix(filepath.Base(fpath), ".gz")
+	fn, err := s.names.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseLevel(fn.Level); err != nil {
+		return nil, fmt.Errorf("%q has invalid level: %w", fpath, err)
+	}
+	v.name, v.host, v.user, v.level = fn.Name, fn.Host, fn.User, fn.Level
+	v.time, v.pid, v.compressed = fn.Time, fn.Pid, compressed
+	return v, nil
+}
+
+// discover returns the log files in the input directories that match the
+// selector. Input files are always included.
+func discover(paths []string, sel *selector) ([]*logFile, error) {
+	var files []*logFile
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			v, err := sel.parseFileName(p)
+			if err != nil {
+				// Arbitrary files are read without any file name metadata.
+				v = &logFile{path: p, compressed: strings.HasSuffix(p, ".gz")}
+			}
+			files = append(files, v)
+			continue
+		}
+
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			v, err := sel.parseFileName(filepath.Join(p, entry.Name()))
+			if err != nil || !sel.match(v) {
+				continue
+			}
+			files = append(files, v)
+		}
+	}
+	return files, nil
+}
+
+// parseLevel parses the log level names used in the log file names. Glog
+// style WARNING level name is also accepted.
+func parseLevel(s string) (slog.Level, error) {
+	switch strings.ToUpper(s) {
+	case "FATAL":
+		return slog.Level(12), nil
+	case "WARNING":
+		return slog.LevelWarn, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, err
+	}
+	return l, nil
+}
+
+// levelName returns the log level name used in the log file names for the
+// log files that include the input level messages.
+func levelName(l slog.Level) string {
+	switch {
+	case l >= slog.Level(12):
+		return "FATAL"
+	case l >= slog.LevelError:
+		return "ERROR"
+	case l >= slog.LevelWarn:
+		return "WARN"
+	case l >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/visvasity/sglog/logparse"
+)
+
+// filter selects the log records to print.
+type filter struct {
+	level slog.Level
+
+	since, until time.Time
+
+	pid int
+
+	// source is a glob pattern for file:line or file of the log statement.
+	source string
+
+	// vmodule is a glob pattern for the vmodule attribute value.
+	vmodule string
+
+	attrs []attrFilter
+}
+
+// attrFilter matches an attribute value with a glob pattern.
+type attrFilter struct {
+	key     string
+	pattern string
+}
+
+// check validates the glob patterns in the filter.
+func (f *filter) check() error {
+	patterns := []string{f.source, f.vmodule}
+	for _, a := range f.attrs {
+		patterns = append(patterns, a.pattern)
+	}
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func (f *filter) match(r *logparse.Record) bool {
+	if r.Level < f.level {
+		return false
+	}
+	if !f.since.IsZero() && r.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !r.Time.Before(f.until) {
+		return false
+	}
+	if f.pid != 0 && r.PID != f.pid {
+		return false
+	}
+	if f.source != "" && !match(f.source, r.Source()) && !match(f.source, r.File) {
+		return false
+	}
+	if f.vmodule != "" {
+		if v, ok := r.Attr("vmodule"); !ok || !match(f.vmodule, v) {
+			return false
+		}
+	}
+	for _, a := range f.attrs {
+		if v, ok := r.Attr(a.key); !ok || !match(a.pattern, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func match(pattern, s string) bool {
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+// parseTime parses an absolute time or a duration before now.
+func parseTime(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	layouts := []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse time %q", s)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/visvasity/sglog/logparse"
+)
+
+// pollInterval is the interval to check the log files for new data and the
+// symbolic links for rotations.
+const pollInterval = 250 * time.Millisecond
+
+// follower follows a log file symbolic link across log file rotations.
+type follower struct {
+	link string
+	sel  *selector
+
+	// target is the log file path the symbolic link pointed to when it was
+	// last opened.
+	target string
+	file   *os.File
+	offset int64
+	year   int
+
+	// partial holds an incomplete last line.
+	partial []byte
+
+	// matched is true if the last log record read from the file was printed,
+	// so that its continuation lines read later are also printed.
+	matched bool
+}
+
+// pending is a log record read from a followed file, to be printed.
+type pending struct {
+	record *logparse.Record
+	text   string
+}
+
+// followLinks follows the log file symbolic links in the input directories
+// and prints the new log messages as they are written. Symbolic links created
+// after the start are also followed.
+func followLinks(w io.Writer, paths []string, sel *selector, f *filter) error {
+	bw := bufio.NewWriter(w)
+	followers := make(map[string]*follower)
+	for first := true; ; first = false {
+		if err := pollLinks(bw, paths, sel, f, followers, first); err != nil {
+			return err
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// pollLinks checks the followed files once for new data and prints the new
+// log messages ordered by their timestamps. New symbolic links are added to
+// the followers.
+func pollLinks(bw *bufio.Writer, paths []string, sel *selector, f *filter, followers map[string]*follower, first bool) error {
+	links, err := findLinks(paths, sel)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if _, ok := followers[link]; !ok {
+			followers[link] = &follower{link: link, sel: sel}
+		}
+	}
+
+	var batch []*pending
+	for _, fw := range followers {
+		items, err := fw.poll(bw, f, first)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, items...)
+	}
+
+	slices.SortStableFunc(batch, func(a, b *pending) int {
+		return a.record.Time.Compare(b.record.Time)
+	})
+	for _, p := range batch {
+		if f.match(p.record) {
+			writeRecord(bw, p.text, p.record)
+		}
+	}
+	return bw.Flush()
+}
+
+// findLinks returns the symbolic links to the log files matching the selector
+// in the input directories. Input symbolic links are always included.
+func findLinks(paths []string, sel *selector) ([]string, error) {
+	var links []string
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			links = append(links, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Type()&os.ModeSymlink == 0 {
+				continue
+			}
+			link := filepath.Join(p, entry.Name())
+			target, err := os.Readlink(link)
+			if err != nil {
+				continue
+			}
+			if v, err := sel.parseFileName(target); err == nil && sel.match(v) {
+				links = append(links, link)
+			}
+		}
+	}
+	return links, nil
+}
+
+// poll reads the new data from the followed file, switching to the new log
+// file if the symbolic link has changed. Continuation lines of the log records
+// printed earlier are written out directly.
+func (fw *follower) poll(w io.Writer, f *filter, first bool) ([]*pending, error) {
+	target, err := filepath.EvalSymlinks(fw.link)
+	if err != nil {
+		// Link may be in the middle of an update.
+		return nil, nil
+	}
+
+	var items []*pending
+	if target != fw.target {
+		if fw.file != nil {
+			// Drain the old log file before switching to the new one.
+			items = fw.read(w, f, items)
+			fw.file.Close()
+			fw.file = nil
+		}
+		fp, err := os.Open(target)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return items, nil
+			}
+			return nil, err
+		}
+		fw.target, fw.file, fw.offset, fw.partial = target, fp, 0, nil
+		fw.year = time.Now().Year()
+		if v, err := fw.sel.parseFileName(target); err == nil {
+			fw.year = v.time.Year()
+		}
+		if first {
+			// Start following from the end of the existing log files.
+			if fw.offset, err = fp.Seek(0, io.SeekEnd); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if fi, err := fw.file.Stat(); err == nil && fi.Size() < fw.offset {
+		// Log file is truncated, so start over.
+		fw.offset, fw.partial = 0, nil
+		if _, err := fw.file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return fw.read(w, f, items), nil
+}
+
+// read reads the complete lines appended to the followed file.
+func (fw *follower) read(w io.Writer, f *filter, items []*pending) []*pending {
+	data, err := io.ReadAll(fw.file)
+	fw.offset += int64(len(data))
+	if err != nil || len(data) == 0 {
+		return items
+	}
+	data = append(fw.partial, data...)
+
+	var last *pending
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(data[:i])
+		data = data[i+1:]
+
+		if h, ok := logparse.ParseHeader(line); ok {
+			if t, ok := h.Time(time.Local); ok {
+				fw.year = t.Year()
+			}
+			last, fw.matched = nil, false
+			continue
+		}
+		if r, err := logparse.ParseInYear(line, fw.year, time.Local); err == nil {
+			last = &pending{record: r, text: line}
+			items = append(items, last)
+			fw.matched = f.match(r)
+			continue
+		}
+		if last != nil {
+			last.record.Continuation = append(last.record.Continuation, line)
+		} else if fw.matched {
+			io.WriteString(w, line)
+			io.WriteString(w, "\n")
+		}
+	}
+	fw.partial = slices.Clone(data)
+	return items
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/visvasity/sglog"
+)
+
+func TestFollowLinks(t *testing.T) {
+	dir, linkDir := t.TempDir(), t.TempDir()
+	tmpl := "{name}.{level}.{time}.{pid}.{seq}.log"
+	backend := sglog.NewBackend(&sglog.Options{
+		Name:                "follow",
+		LogDirs:             []string{dir},
+		LogLinkDir:          linkDir,
+		LogFileNameTemplate: tmpl,
+		LogFileMaxSize:      1024,
+	})
+	defer backend.Close()
+	logger := slog.New(backend.Handler())
+	logger.Info("message before follow")
+	backend.Flush()
+
+	sel, err := newSelector("follow", "INFO", tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	followers := make(map[string]*follower)
+	poll := func(first bool) string {
+		t.Helper()
+		buf.Reset()
+		if err := pollLinks(bw, []string{linkDir}, sel, &filter{level: slog.LevelInfo}, followers, first); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	// Following starts at the end of the existing log files.
+	if out := poll(true); out != "" {
+		t.Fatalf("want no output at the start, got %q", out)
+	}
+	if len(followers) != 1 {
+		t.Fatalf("want one followed symbolic link, got %d", len(followers))
+	}
+
+	logger.Info("first new message", "user", "alice")
+	backend.Flush()
+	out := poll(false)
+	if !strings.Contains(out, "first new message") || strings.Contains(out, "message before follow") {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	// Lines appended to the log file by other writers are printed too.
+	path := backend.LogFiles()[slog.LevelInfo]
+	fp, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fp.WriteString("I0307 13:45:10.000000    1234 other.go:10] appended message\n"); err != nil {
+		t.Fatal(err)
+	}
+	fp.Close()
+	if out := poll(false); !strings.Contains(out, "appended message") {
+		t.Fatalf("want appended message, got %q", out)
+	}
+
+	// Log files are followed across rotations.
+	logger.Info(strings.Repeat("x", 1024))
+	logger.Info("message after rotation")
+	backend.Flush()
+	if newPath := backend.LogFiles()[slog.LevelInfo]; newPath == path {
+		t.Fatalf("want log file rotation")
+	}
+	if out := poll(false); !strings.Contains(out, "message after rotation") {
+		t.Fatalf("want message after rotation, got %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(linkDir, "follow.INFO")); err != nil {
+		t.Fatal(err)
+	}
+}
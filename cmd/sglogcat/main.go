@@ -0,0 +1,118 @@
+// Command sglogcat prints, merges and follows the log files written by the
+// sglog package.
+//
+// Log files are discovered in the input directories using the sglog log file
+// naming scheme, which is
+//
+//	<name>.<host>.<user>.log.<LEVEL>.<yyyymmdd-hhmmss>.<pid>
+//
+// by default, or the log file name template given with the -log_file_name
+// flag, same as the sglog -log_file_name flag. Log files are read for a single
+// level, because log files for a level also include all higher level log
+// messages. Log messages from all matching log files, including the compressed
+// and rotated log files from multiple processes, are merged by their
+// timestamps.
+//
+// With the -f flag, sglogcat follows the log file symbolic links, like
+// <name>.<LEVEL>, in the input directories similar to tail -F, so that new log
+// files are picked up when log files are rotated or processes are restarted.
+//
+// Usage:
+//
+//	sglogcat [flags] [dir|file ...]
+//
+// Examples:
+//
+//	sglogcat -name server -level WARNING /var/log/server
+//	sglogcat -since 1h -source 'rpc*.go:*' -attr 'user=alice' /var/log/server
+//	sglogcat -f -vmodule network /var/log/links
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/visvasity/sglog"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("sglogcat: ")
+
+	var (
+		f      filter
+		follow bool
+		name   string
+		level  string
+		tmpl   string
+	)
+	flag.StringVar(&name, "name", "", "program name of the log files (default all programs)")
+	flag.StringVar(&level, "level", "INFO", "minimum log level: DEBUG, INFO, WARN, ERROR or FATAL")
+	flag.StringVar(&tmpl, "log_file_name", sglog.DefaultFileNameTemplate, "log file name template of the log files, same as the sglog -log_file_name flag")
+	flag.BoolVar(&follow, "f", false, "follow the log file symbolic links across rotations, like tail -F")
+	flag.Func("since", "print log messages at or after this time (RFC3339, date, date and time, or a duration before now)", func(s string) (err error) {
+		f.since, err = parseTime(s)
+		return err
+	})
+	flag.Func("until", "print log messages before this time (RFC3339, date, date and time, or a duration before now)", func(s string) (err error) {
+		f.until, err = parseTime(s)
+		return err
+	})
+	flag.IntVar(&f.pid, "pid", 0, "print log messages from this process id only")
+	flag.StringVar(&f.source, "source", "", "glob pattern matched against the file:line or file of log messages")
+	flag.StringVar(&f.vmodule, "vmodule", "", "glob pattern matched against the vmodule attribute of log messages")
+	flag.Func("attr", "key=pattern glob to match attribute values; can be repeated", func(s string) error {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("attribute filter %q is not in key=pattern form", s)
+		}
+		f.attrs = append(f.attrs, attrFilter{key: k, pattern: v})
+		return nil
+	})
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: sglogcat [flags] [dir|file ...]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	lvl, err := parseLevel(level)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f.level = lvl
+	if err := f.check(); err != nil {
+		log.Fatal(err)
+	}
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	sel, err := newSelector(name, levelName(lvl), tmpl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if follow {
+		err = followLinks(os.Stdout, paths, sel, &f)
+	} else {
+		err = printFiles(os.Stdout, paths, sel, &f)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printFiles prints the filtered log messages from all matching log files
+// merged by their timestamps.
+func printFiles(w io.Writer, paths []string, sel *selector, f *filter) error {
+	files, err := discover(paths, sel)
+	if err != nil {
+		return err
+	}
+	return merge(w, files, f)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/visvasity/sglog"
+)
+
+func TestParseFileName(t *testing.T) {
+	sel, err := newSelector("", "INFO", sglog.DefaultFileNameTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := sel.parseFileName("/var/log/my.server.host.user.log.WARN.20240307-134510.1234.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.name != "my.server" || v.host != "host" || v.user != "user" || v.level != "WARN" || v.pid != 1234 || !v.compressed {
+		t.Errorf("unexpected log file %+v", v)
+	}
+	if v.time.Year() != 2024 || v.time.Hour() != 13 {
+		t.Errorf("unexpected log file time %v", v.time)
+	}
+
+	for _, name := range []string{"server.INFO", "server.host.user.txt.INFO.20240307-134510.1234", "server.host.user.log.INFO.2024.1234"} {
+		if _, err := sel.parseFileName(name); err == nil {
+			t.Errorf("%q must not be parsed as a log file name", name)
+		}
+	}
+
+	// Log file names with a custom template.
+	sel, err = newSelector("", "INFO", "{name}_{level}_{time}_{pid}_{seq}.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = sel.parseFileName("/var/log/my.server_ERROR_20240307-134510_1234_2.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.name != "my.server" || v.level != "ERROR" || v.pid != 1234 || v.compressed || v.time.Year() != 2024 {
+		t.Errorf("unexpected log file %+v", v)
+	}
+	if _, err := sel.parseFileName("/var/log/my.server.host.user.log.WARN.20240307-134510.1234"); err == nil {
+		t.Errorf("default log file names must not match the custom template")
+	}
+}
+
+func TestPrintFiles(t *testing.T) {
+	dir := t.TempDir()
+	backend := sglog.NewBackend(&sglog.Options{
+		Name:    "sglogcat",
+		LogDirs: []string{dir},
+	})
+	logger := slog.New(backend.Handler())
+	network := sglog.VModule("sglogcat-network", slog.LevelInfo)
+	logger.Info("first message", "user", "alice")
+	logger.With(network).Warn("second message", "user", "bob")
+	logger.Error("third message", "user", "alice")
+	backend.Close()
+
+	// Files created by other programs must be ignored.
+	if err := os.WriteFile(filepath.Join(dir, "other.txt"), []byte("some data\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		filter filter
+		want   []string
+	}{
+		{filter{level: slog.LevelInfo}, []string{"first message", "second message", "third message"}},
+		{filter{level: slog.LevelWarn}, []string{"second message", "third message"}},
+		{filter{attrs: []attrFilter{{key: "user", pattern: "al*"}}}, []string{"first message", "third message"}},
+		{filter{vmodule: "*network"}, []string{"second message"}},
+		{filter{source: "main_test.go:*"}, []string{"first message", "second message", "third message"}},
+		{filter{source: "other.go"}, nil},
+	}
+
+	sel, err := newSelector("sglogcat", "INFO", sglog.DefaultFileNameTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range testcases {
+		var buf bytes.Buffer
+		if err := printFiles(&buf, []string{dir}, sel, &tc.filter); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(tc.want) == 0 {
+			if buf.Len() != 0 {
+				t.Errorf("%d: want no output, got %q", i, buf.String())
+			}
+			continue
+		}
+		if len(lines) != len(tc.want) {
+			t.Errorf("%d: got %q, want %q", i, lines, tc.want)
+			continue
+		}
+		for j := range lines {
+			if !strings.Contains(lines[j], tc.want[j]) {
+				t.Errorf("%d: line %q doesn't contain %q", i, lines[j], tc.want[j])
+			}
+		}
+	}
+}
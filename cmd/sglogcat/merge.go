@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"io"
+
+	"github.com/visvasity/sglog/logparse"
+)
+
+// source reads the log records from a log file in order.
+type source struct {
+	file    *logFile
+	rc      io.ReadCloser
+	scanner *logparse.Scanner
+
+	// record is the current record, if any.
+	record *logparse.Record
+	text   string
+}
+
+func openSource(v *logFile) (*source, error) {
+	rc, err := v.open()
+	if err != nil {
+		return nil, err
+	}
+	s := &source{file: v, rc: rc, scanner: logparse.NewScanner(rc)}
+	if !v.time.IsZero() {
+		s.scanner.Year = v.time.Year()
+	}
+	return s, nil
+}
+
+// next advances to the next log record. Returns false at the end of file.
+func (s *source) next() bool {
+	for s.scanner.Scan() {
+		if r := s.scanner.Record(); r != nil {
+			s.record, s.text = r, s.scanner.Text()
+			return true
+		}
+	}
+	s.record = nil
+	return false
+}
+
+func (s *source) close() error {
+	return s.rc.Close()
+}
+
+// sourceHeap orders the sources by their current record times.
+type sourceHeap []*source
+
+func (h sourceHeap) Len() int { return len(h) }
+
+func (h sourceHeap) Less(i, j int) bool {
+	return h[i].record.Time.Before(h[j].record.Time)
+}
+
+func (h sourceHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *sourceHeap) Push(x any) { *h = append(*h, x.(*source)) }
+
+func (h *sourceHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// merge prints the matching log records from all log files ordered by their
+// timestamps.
+func merge(w io.Writer, files []*logFile, f *filter) error {
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	var h sourceHeap
+	defer func() {
+		for _, s := range h {
+			s.close()
+		}
+	}()
+
+	for _, v := range files {
+		s, err := openSource(v)
+		if err != nil {
+			return err
+		}
+		if !s.next() {
+			if err := s.scanner.Err(); err != nil {
+				s.close()
+				return err
+			}
+			s.close()
+			continue
+		}
+		h = append(h, s)
+	}
+	heap.Init(&h)
+
+	for len(h) > 0 {
+		s := h[0]
+		if f.match(s.record) {
+			writeRecord(bw, s.text, s.record)
+		}
+		if s.next() {
+			heap.Fix(&h, 0)
+			continue
+		}
+		heap.Pop(&h)
+		err := s.scanner.Err()
+		s.close()
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeRecord writes the log line and its continuation lines.
+func writeRecord(w io.Writer, text string, r *logparse.Record) {
+	io.WriteString(w, text)
+	io.WriteString(w, "\n")
+	for _, line := range r.Continuation {
+		io.WriteString(w, line)
+		io.WriteString(w, "\n")
+	}
+}
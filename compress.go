@@ -0,0 +1,67 @@
+package sglog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+)
+
+// compressSuffix is the file name suffix for the compressed log files.
+const compressSuffix = ".gz"
+
+// compressFile compresses a rotated log file in a background goroutine. Log
+// file is replaced by the compressed file only after it is fully written, so
+// an incomplete compressed file is never left behind with the final name.
+func (v *Backend) compressFile(f *levelFile, fpath string) {
+	// Backend.Close waits for the compression goroutines too.
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+
+		if err := gzipFile(fpath, v.opts.LogFileMode); err != nil {
+			v.reportError(ErrorCompress, f, fpath, err)
+		}
+	}()
+}
+
+func gzipFile(fpath string, mode os.FileMode) (status error) {
+	in, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := fpath + compressSuffix + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if status != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fpath+compressSuffix); err != nil {
+		return err
+	}
+	if err := os.Remove(fpath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
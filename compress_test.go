@@ -0,0 +1,65 @@
+package sglog
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:            "compress",
+		LogDirs:         []string{dir},
+		LogFileCompress: true,
+	})
+
+	logger := slog.New(backend.Handler())
+	logger.Info("first file message")
+
+	f := backend.fileMap[slog.LevelInfo]
+	backend.mu.Lock()
+	first := f.file.Name()
+	err := f.rotateFile(time.Now().Add(time.Hour))
+	backend.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("second file message")
+	backend.Close()
+
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Fatalf("rotated file %q must be removed after compression: %v", first, err)
+	}
+	zfile, err := os.Open(first + compressSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zfile.Close()
+	zr, err := gzip.NewReader(zfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "first file message") {
+		t.Fatalf("compressed file has unexpected content %q", data)
+	}
+
+	// Compressed files must never be picked for reuse.
+	last, err := f.lastFileName(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasSuffix(last, compressSuffix) || filepath.Join(dir, last) == first {
+		t.Fatalf("unexpected last file name %q", last)
+	}
+}
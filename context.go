@@ -0,0 +1,131 @@
+package sglog
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// levelKey is the context key for the context-scoped log levels.
+type levelKey struct{}
+
+// WithLevel returns a copy of the context with a log level override. Log
+// messages written with the context (e.g., with slog.DebugContext) are enabled
+// at this level even when the backend or module log levels are higher, which
+// can be used to debug a single request without changing the process-wide log
+// levels.
+//
+// Context log level can only make logging more verbose; log messages below the
+// backend's log level are still written if they are at or above the context
+// log level.
+func WithLevel(ctx context.Context, level slog.Level) context.Context {
+	return context.WithValue(ctx, levelKey{}, level)
+}
+
+// ContextLevel returns the log level override from the context, if any.
+func ContextLevel(ctx context.Context) (slog.Level, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	level, ok := ctx.Value(levelKey{}).(slog.Level)
+	return level, ok
+}
+
+// ContextExtractor extracts log attributes from a context. Attributes from the
+// configured extractors are added to every log message written with a context,
+// as in slog.InfoContext, outside of all groups.
+type ContextExtractor interface {
+	Extract(ctx context.Context) []slog.Attr
+}
+
+// ContextExtractorFunc adapts a function into a ContextExtractor.
+type ContextExtractorFunc func(ctx context.Context) []slog.Attr
+
+// Extract implements the ContextExtractor interface.
+func (f ContextExtractorFunc) Extract(ctx context.Context) []slog.Attr {
+	return f(ctx)
+}
+
+// Attribute keys used by the TraceParentExtractor.
+const (
+	traceIDKey = "trace_id"
+	spanIDKey  = "span_id"
+)
+
+// TraceParent holds the identifiers from a W3C Trace Context traceparent value.
+type TraceParent struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// ParseTraceParent parses a W3C Trace Context traceparent value, like
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceParent(s string) (TraceParent, error) {
+	var tp TraceParent
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return tp, fmt.Errorf("invalid traceparent %q: %w", s, os.ErrInvalid)
+	}
+	var version [1]byte
+	if _, err := hex.Decode(version[:], []byte(s[:2])); err != nil || version[0] == 0xff {
+		return tp, fmt.Errorf("invalid traceparent version in %q: %w", s, os.ErrInvalid)
+	}
+	// Future versions can append more fields.
+	if len(s) > 55 && (version[0] == 0 || s[55] != '-') {
+		return tp, fmt.Errorf("invalid traceparent %q: %w", s, os.ErrInvalid)
+	}
+	if _, err := hex.Decode(tp.TraceID[:], []byte(s[3:35])); err != nil || tp.TraceID == [16]byte{} {
+		return tp, fmt.Errorf("invalid trace id in traceparent %q: %w", s, os.ErrInvalid)
+	}
+	if _, err := hex.Decode(tp.SpanID[:], []byte(s[36:52])); err != nil || tp.SpanID == [8]byte{} {
+		return tp, fmt.Errorf("invalid parent id in traceparent %q: %w", s, os.ErrInvalid)
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(s[53:55])); err != nil {
+		return tp, fmt.Errorf("invalid trace flags in traceparent %q: %w", s, os.ErrInvalid)
+	}
+	tp.Flags = flags[0]
+	return tp, nil
+}
+
+// String returns the traceparent value in the W3C Trace Context format.
+func (tp TraceParent) String() string {
+	return fmt.Sprintf("00-%x-%x-%02x", tp.TraceID, tp.SpanID, tp.Flags)
+}
+
+// traceParentKey is the context key for the W3C traceparent values.
+type traceParentKey struct{}
+
+// WithTraceParent returns a copy of the context with the traceparent value.
+func WithTraceParent(ctx context.Context, tp TraceParent) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, tp)
+}
+
+// ContextTraceParent returns the traceparent value from the context, if any.
+func ContextTraceParent(ctx context.Context) (TraceParent, bool) {
+	if ctx == nil {
+		return TraceParent{}, false
+	}
+	tp, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return tp, ok
+}
+
+// TraceParentExtractor adds the trace_id and span_id attributes from the
+// traceparent value stored in the context with WithTraceParent. It is enabled
+// by default.
+type TraceParentExtractor struct{}
+
+// Extract implements the ContextExtractor interface.
+func (TraceParentExtractor) Extract(ctx context.Context) []slog.Attr {
+	tp, ok := ContextTraceParent(ctx)
+	if !ok {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String(traceIDKey, hex.EncodeToString(tp.TraceID[:])),
+		slog.String(spanIDKey, hex.EncodeToString(tp.SpanID[:])),
+	}
+}
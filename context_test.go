@@ -0,0 +1,113 @@
+package sglog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContextLevel(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:    "ctxlevel",
+		LogDirs: []string{dir},
+	})
+
+	logger := slog.New(backend.Handler())
+	ctx := WithLevel(context.Background(), slog.LevelDebug)
+
+	logger.Debug("debug without context level")
+	logger.DebugContext(context.Background(), "debug with background context")
+	logger.DebugContext(ctx, "debug with context level")
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		t.Fatalf("want debug level enabled with the context level")
+	}
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatalf("want debug level disabled without the context level")
+	}
+	backend.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "ctxlevel.DEBUG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("debug with context level")) {
+		t.Fatalf("want debug message with the context level")
+	}
+	if bytes.Contains(data, []byte("without context level")) || bytes.Contains(data, []byte("background context")) {
+		t.Fatalf("want no debug messages without the context level")
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	testcases := []struct {
+		value string
+		valid bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+	}
+
+	for i, tc := range testcases {
+		tp, err := ParseTraceParent(tc.value)
+		if tc.valid != (err == nil) {
+			t.Errorf("%d: ParseTraceParent(%q) returned %v", i, tc.value, err)
+			continue
+		}
+		if err == nil && tc.value[:2] == "00" && tp.String() != tc.value {
+			t.Errorf("%d: want %q, got %q", i, tc.value, tp.String())
+		}
+	}
+}
+
+func TestContextExtractors(t *testing.T) {
+	dir := t.TempDir()
+	type requestKey struct{}
+	backend := NewBackend(&Options{
+		Name:    "extractors",
+		LogDirs: []string{dir},
+		ContextExtractors: []ContextExtractor{
+			TraceParentExtractor{},
+			ContextExtractorFunc(func(ctx context.Context) []slog.Attr {
+				if id, ok := ctx.Value(requestKey{}).(string); ok {
+					return []slog.Attr{slog.String("request_id", id)}
+				}
+				return nil
+			}),
+		},
+	})
+
+	tp, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithTraceParent(context.Background(), tp)
+	ctx = context.WithValue(ctx, requestKey{}, "r1")
+
+	logger := slog.New(backend.Handler()).WithGroup("group")
+	logger.InfoContext(ctx, "traced message", "key", "value")
+	logger.InfoContext(context.Background(), "untraced message")
+	backend.Close()
+
+	lines := readLines(t, filepath.Join(dir, "extractors.INFO"))
+	if len(lines) != 2 {
+		t.Fatalf("want 2 log lines, got %d", len(lines))
+	}
+	want := `traced message group.key="value" trace_id="4bf92f3577b34da6a3ce929d0e0e4736" span_id="00f067aa0ba902b7" request_id="r1"`
+	if !strings.HasSuffix(lines[0], want) {
+		t.Fatalf("want suffix %q, got %q", want, lines[0])
+	}
+	if strings.Contains(lines[1], "trace_id") {
+		t.Fatalf("want no trace attributes, got %q", lines[1])
+	}
+}
@@ -0,0 +1,115 @@
+package sglog
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// repeatState holds the last log message written to a log file and the number
+// of times it has been repeated since.
+type repeatState struct {
+	key   string
+	pc    uintptr
+	level slog.Level
+
+	// count is the number of repeats not yet reported in a summary line, and
+	// first and last are the times of the first and last such repeats.
+	count       int
+	first, last time.Time
+}
+
+// dedupKey returns a key that identifies identical log records, ignoring
+// their times.
+func dedupKey(r slog.Record) string {
+	var buf bytes.Buffer
+	buf.Write(strconv.AppendUint(nil, uint64(r.PC), 16))
+	buf.WriteByte(' ')
+	buf.WriteString(r.Level.String())
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		appendTextAttr(&buf, a, "")
+		return true
+	})
+	return buf.String()
+}
+
+// writeEntry writes the log entry to the log file, collapsing the repeated
+// log messages if enabled. Repeats are not collapsed when the log file must be
+// rotated, so that the summary is written to the current log file and the
+// repeated log message starts the new log file.
+func (f *levelFile) writeEntry(e *logEntry) error {
+	if e.dedupKey != "" && f.repeat != nil && f.repeat.key == e.dedupKey && !f.rotationDue(time.Now()) {
+		if f.repeat.count == 0 {
+			f.repeat.first = e.time
+		}
+		f.repeat.count++
+		f.repeat.last = e.time
+		return nil
+	}
+
+	f.flushRepeats()
+	if _, err := f.Write(e.msg); err != nil {
+		f.repeat = nil
+		return err
+	}
+	f.repeat = nil
+	if e.dedupKey != "" {
+		f.repeat = &repeatState{key: e.dedupKey, pc: e.pc, level: e.maxLevel}
+	}
+	return nil
+}
+
+// flushRepeats writes the summary line for the repeats of the last log
+// message, if any, to the current log file. Last log message is remembered,
+// so that further repeats are collapsed again. Errors are also reported to the
+// backend's error handler.
+func (f *levelFile) flushRepeats() error {
+	if f.repeat == nil || f.repeat.count == 0 || f.file == nil {
+		return nil
+	}
+	rs := *f.repeat
+	f.repeat.count = 0
+
+	r := slog.NewRecord(rs.last, rs.level, fmt.Sprintf("previous message repeated %d times", rs.count), rs.pc)
+	r.AddAttrs(
+		slog.Int("repeated", rs.count),
+		slog.Time("first", rs.first),
+		slog.Time("last", rs.last),
+	)
+	var buf bytes.Buffer
+	f.backend.format(&buf, r)
+	if _, err := f.write(buf.Bytes()); err != nil {
+		f.backend.reportError(ErrorWrite, f, f.file.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// flushRepeatsLoop periodically writes the summary lines for the log messages
+// repeated for longer than the dedup timeout, till the backend is closed.
+func (v *Backend) flushRepeatsLoop() {
+	defer v.wg.Done()
+
+	timeout := v.opts.LogDedupTimeout
+	ticker := time.NewTicker(max(timeout/4, 10*time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-v.done:
+			return
+		case now := <-ticker.C:
+			v.mu.Lock()
+			for _, f := range v.fileMap {
+				if f.repeat != nil && f.repeat.count > 0 && now.Sub(f.repeat.first) >= timeout {
+					f.flushRepeats()
+				}
+			}
+			v.mu.Unlock()
+		}
+	}
+}
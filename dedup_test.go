@@ -0,0 +1,109 @@
+package sglog
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:            "dedup",
+		LogDirs:         []string{dir},
+		LogDedupTimeout: time.Hour,
+	})
+
+	logger := slog.New(backend.Handler())
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 10; j++ {
+			logger.Info("repeated message", "key", "value")
+		}
+		logger.Info("other message", "iteration", i)
+	}
+	backend.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "dedup.INFO"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("repeated message")); n != 5 {
+		t.Fatalf("want 5 repeated messages, got %d", n)
+	}
+	if n := bytes.Count(data, []byte("previous message repeated 9 times repeated=9")); n != 5 {
+		t.Fatalf("want 5 summary lines, got %d", n)
+	}
+	if n := bytes.Count(data, []byte("other message")); n != 5 {
+		t.Fatalf("want 5 other messages, got %d", n)
+	}
+}
+
+func TestDedupTimeout(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:            "dedup",
+		LogDirs:         []string{dir},
+		LogDedupTimeout: 50 * time.Millisecond,
+	})
+	defer backend.Close()
+
+	logger := slog.New(backend.Handler())
+	for i := 0; i < 3; i++ {
+		logger.Info("repeated message")
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	data, err := os.ReadFile(filepath.Join(dir, "dedup.INFO"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("previous message repeated 2 times")) {
+		t.Fatalf("want summary line after the timeout, got %q", data)
+	}
+}
+
+func TestDedupRotation(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:                  "dedup",
+		LogDirs:               []string{dir},
+		LogDedupTimeout:       time.Hour,
+		LogFileRotateInterval: time.Hour,
+	})
+	defer backend.Close()
+
+	logger := slog.New(backend.Handler())
+	for i := 0; i < 3; i++ {
+		logger.Info("repeated message")
+	}
+	path := backend.LogFiles()[slog.LevelInfo]
+
+	// Repeats are not collapsed when the log file must be rotated.
+	f := backend.fileMap[slog.LevelInfo]
+	backend.mu.Lock()
+	f.rotateAt = time.Now().Add(-time.Second)
+	backend.mu.Unlock()
+	logger.Info("repeated message")
+
+	newPath := backend.LogFiles()[slog.LevelInfo]
+	if newPath == path {
+		t.Fatalf("want log file rotation for a repeated message")
+	}
+	old, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(old, []byte("previous message repeated 2 times")) {
+		t.Errorf("want summary line in the rotated log file, got %q", old)
+	}
+	data, err := os.ReadFile(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("repeated message")); n != 1 {
+		t.Errorf("want the repeated message in the new log file, got %q", data)
+	}
+}
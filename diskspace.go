@@ -0,0 +1,138 @@
+package sglog
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+)
+
+// diskCheckInterval is the interval for the periodic free disk space checks.
+const diskCheckInterval = 10 * time.Second
+
+// getFreeSpace is freeSpace, but can be replaced in the tests.
+var getFreeSpace = freeSpace
+
+// diskState holds the result of a free disk space check.
+type diskState struct {
+	// lowDirs holds the log directories with less free space than
+	// LogDirMinFreeSpace or that could not be checked.
+	lowDirs map[string]bool
+
+	// preferred is the first log directory with enough free space, or empty
+	// if all log directories are low on space, in which case the backend is
+	// in the degraded mode.
+	preferred string
+}
+
+// degraded returns true if all log directories are low on free space.
+func (ds *diskState) degraded() bool {
+	return ds != nil && ds.preferred == ""
+}
+
+// logDirs returns the log directories in the order they should be tried for
+// new log files. Directories low on free space are moved to the end.
+func (v *Backend) logDirs() []string {
+	ds := v.disk.Load()
+	if ds == nil || len(ds.lowDirs) == 0 {
+		return v.opts.LogDirs
+	}
+	dirs := make([]string, 0, len(v.opts.LogDirs))
+	for _, dir := range v.opts.LogDirs {
+		if !ds.lowDirs[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range v.opts.LogDirs {
+		if ds.lowDirs[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// checkDiskSpace checks the free space in all log directories and updates the
+// disk state. State is replaced only when it changes, so that log files are
+// moved between the log directories only when necessary. Checks from the
+// periodic loop and the log file rotations are serialized, so that entering
+// the degraded mode is reported exactly once.
+func (v *Backend) checkDiskSpace() {
+	if v.opts.LogDirMinFreeSpace == 0 {
+		return
+	}
+
+	v.diskMu.Lock()
+	defer v.diskMu.Unlock()
+
+	ds := &diskState{lowDirs: make(map[string]bool)}
+	for _, dir := range v.opts.LogDirs {
+		free, err := getFreeSpace(dir)
+		if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+			// Missing or inaccessible directories cannot hold the log files.
+			ds.lowDirs[dir] = true
+			continue
+		}
+		if err == nil && free < v.opts.LogDirMinFreeSpace {
+			ds.lowDirs[dir] = true
+			continue
+		}
+		if ds.preferred == "" {
+			ds.preferred = dir
+		}
+	}
+
+	old := v.disk.Load()
+	if old != nil && old.preferred == ds.preferred && maps.Equal(old.lowDirs, ds.lowDirs) {
+		return
+	}
+	v.disk.Store(ds)
+
+	if ds.degraded() && !old.degraded() {
+		err := fmt.Errorf("free space is below %d bytes in all log directories; DEBUG and INFO log messages are dropped", v.opts.LogDirMinFreeSpace)
+		v.reportError(ErrorDiskSpace, nil, "", err)
+	}
+}
+
+// lowDiskMinLevel returns the lowest level file a log message with the input
+// minimum level can be written to. In the degraded mode, DEBUG and INFO level
+// files are skipped for all log messages, including the WARN and above log
+// messages.
+func (v *Backend) lowDiskMinLevel(level slog.Level) slog.Level {
+	if v.disk.Load().degraded() {
+		return max(level, slog.LevelWarn)
+	}
+	return level
+}
+
+// dropLowDisk returns true if a log message at the input level must be
+// dropped because of the degraded mode without formatting it, because it
+// would only be written to the DEBUG and INFO log files.
+func (v *Backend) dropLowDisk(level slog.Level) bool {
+	return level < slog.LevelWarn && v.recent == nil && !v.toStderr(level) && v.disk.Load().degraded()
+}
+
+// moveDir returns true if the level file must be rotated into another log
+// directory because of a change in the free disk space.
+func (f *levelFile) moveDir() bool {
+	ds := f.backend.disk.Load()
+	return ds != nil && ds != f.disk && ds.preferred != "" && ds.preferred != f.dir
+}
+
+// diskCheckLoop periodically checks the free disk space in the log
+// directories, till the backend is closed.
+func (v *Backend) diskCheckLoop() {
+	defer v.wg.Done()
+
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-v.done:
+			return
+		case <-ticker.C:
+			v.checkDiskSpace()
+		}
+	}
+}
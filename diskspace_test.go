@@ -0,0 +1,198 @@
+package sglog
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDiskSpaceGuard(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+
+	var mu sync.Mutex
+	free := map[string]uint64{dir1: 1 << 30, dir2: 1 << 30}
+	setFree := func(dir string, n uint64) {
+		mu.Lock()
+		free[dir] = n
+		mu.Unlock()
+	}
+
+	getFreeSpace = func(dir string) (uint64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if n, ok := free[dir]; ok {
+			return n, nil
+		}
+		return 1 << 30, nil
+	}
+	defer func() { getFreeSpace = freeSpace }()
+
+	var errsMu sync.Mutex
+	var errs []*LogError
+	lastErr := func() *LogError {
+		errsMu.Lock()
+		defer errsMu.Unlock()
+		if len(errs) == 0 {
+			return nil
+		}
+		return errs[len(errs)-1]
+	}
+	formatter := new(countingFormatter)
+	backend := NewBackend(&Options{
+		Name:               "disk",
+		LogDirs:            []string{dir1, dir2},
+		LogDirMinFreeSpace: 1 << 20,
+		Formatter:          formatter,
+		ErrorHandler: func(err *LogError) {
+			errsMu.Lock()
+			errs = append(errs, err)
+			errsMu.Unlock()
+		},
+	})
+	defer backend.Close()
+
+	logger := slog.New(backend.Handler())
+	logger.Info("first message")
+	if path := backend.LogFiles()[slog.LevelInfo]; !strings.HasPrefix(path, dir1) {
+		t.Fatalf("want log file in %q, got %q", dir1, path)
+	}
+
+	// Log files move to the next log directory when the first one is full.
+	setFree(dir1, 0)
+	backend.checkDiskSpace()
+	logger.Info("second message")
+	if path := backend.LogFiles()[slog.LevelInfo]; !strings.HasPrefix(path, dir2) {
+		t.Fatalf("want log file in %q, got %q", dir2, path)
+	}
+
+	// All log directories, including the default one, are full.
+	for _, dir := range backend.opts.LogDirs {
+		setFree(dir, 0)
+	}
+	backend.checkDiskSpace()
+	if !backend.Health().Degraded {
+		t.Fatalf("want degraded mode")
+	}
+	if err := lastErr(); err == nil || err.Kind != ErrorDiskSpace {
+		t.Fatalf("want disk space error, got %v", err)
+	}
+	formatted := formatter.n.Load()
+	logger.Info("dropped message")
+	if n := formatter.n.Load(); n != formatted {
+		t.Fatalf("want dropped message not formatted, got %d formats", n-formatted)
+	}
+	logger.Warn("warning message")
+	degradedInfo := backend.LogFiles()[slog.LevelInfo]
+	degradedWarn := backend.LogFiles()[slog.LevelWarn]
+	if n := backend.Stats().LowDiskDropped; n != 1 {
+		t.Fatalf("want 1 dropped message, got %d", n)
+	}
+
+	// Log files move back to the first log directory when space is freed.
+	setFree(dir1, 1<<30)
+	backend.checkDiskSpace()
+	if backend.Health().Degraded {
+		t.Fatalf("want no degraded mode")
+	}
+	logger.Info("third message")
+	path := backend.LogFiles()[slog.LevelInfo]
+	if !strings.HasPrefix(path, dir1) {
+		t.Fatalf("want log file in %q, got %q", dir1, path)
+	}
+	backend.Flush()
+
+	// Log files are read in the order of their names, which have their
+	// creation times.
+	paths, err := filepath.Glob(filepath.Join(dir1, "disk.*.INFO.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, path := range paths {
+		for _, line := range readLines(t, path) {
+			if _, msg, ok := strings.Cut(line, "] "); ok {
+				messages = append(messages, msg)
+			}
+		}
+	}
+	if want := "first message,third message"; strings.Join(messages, ",") != want {
+		t.Fatalf("want messages %q, got %q", want, messages)
+	}
+
+	// Warning messages skip the INFO log file in the degraded mode.
+	if data, err := os.ReadFile(degradedInfo); err != nil || strings.Contains(string(data), "warning message") {
+		t.Fatalf("want no warning message in the INFO log file %q, got %q (%v)", degradedInfo, data, err)
+	}
+	if data, err := os.ReadFile(degradedWarn); err != nil || !strings.Contains(string(data), "warning message") {
+		t.Fatalf("want warning message in the WARN log file %q, got %q (%v)", degradedWarn, data, err)
+	}
+}
+
+// countingFormatter is a TextFormatter that counts the formatted records.
+type countingFormatter struct {
+	TextFormatter
+	n atomic.Int64
+}
+
+func (f *countingFormatter) Format(buf *bytes.Buffer, r slog.Record) {
+	f.n.Add(1)
+	f.TextFormatter.Format(buf, r)
+}
+
+func TestDiskSpaceConcurrentChecks(t *testing.T) {
+	var free atomic.Uint64
+	free.Store(1 << 30)
+	var release atomic.Pointer[chan struct{}]
+	getFreeSpace = func(dir string) (uint64, error) {
+		// Hold the checks till all of them are started, so that unserialized
+		// checks would update the disk state at the same time.
+		if ch := release.Load(); ch != nil {
+			<-*ch
+		}
+		return free.Load(), nil
+	}
+	defer func() { getFreeSpace = freeSpace }()
+
+	var reports atomic.Int64
+	backend := NewBackend(&Options{
+		Name:               "disk",
+		LogDirs:            []string{t.TempDir()},
+		LogDirMinFreeSpace: 1 << 20,
+		ErrorHandler: func(err *LogError) {
+			if err.Kind == ErrorDiskSpace {
+				reports.Add(1)
+			}
+		},
+	})
+	defer backend.Close()
+
+	// Entering the degraded mode is reported once, however many checks see it.
+	for i := 0; i < 20; i++ {
+		free.Store(0)
+		ch := make(chan struct{})
+		release.Store(&ch)
+		var wg sync.WaitGroup
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				backend.checkDiskSpace()
+			}()
+		}
+		time.Sleep(time.Millisecond)
+		close(ch)
+		wg.Wait()
+		release.Store(nil)
+		free.Store(1 << 30)
+		backend.checkDiskSpace()
+	}
+	if n := reports.Load(); n != 20 {
+		t.Fatalf("want 20 disk space reports, got %d", n)
+	}
+}
@@ -10,8 +10,14 @@
 // # Differences from Google's glog
 //
 //   - Log messages are not buffered inline with the application control flow.
+//     They are written synchronously by default, or with Options.LogQueueSize,
+//     queued for a background goroutine that writes them asynchronously. When
+//     the queue is full, Options.LogQueuePolicy blocks the caller (default),
+//     drops the newest or drops the oldest queued log message. FATAL log
+//     messages are never dropped.
 //   - The standard log/slog package does not define a Fatal level, so FATAL
-//     messages and log files are not supported.
+//     messages must be logged with LevelFatal or the Fatal and FatalContext
+//     functions.
 //   - Global flags from glog are replaced with an Options struct for configuration.
 //   - Unlike glog, this package does not add a footer message when rotating log files.
 //   - When log file reuse is enabled, log file names may not precisely reflect
@@ -25,7 +31,120 @@
 // mitigates this by enabling log file reuse with a configurable timeout (e.g.,
 // one log file per hour).
 //
+// Log files are locked with advisory file locks (where supported) while they
+// are open, and log files locked by another live process are never reused.
+// All writes are appends, so log files shared by multiple processes are not
+// overwritten.
+//
 // Log files are still rotated when they reach the configured maximum size limit.
+// Rotated log files can be compressed with gzip in the background, in which
+// case they are never reused.
+//
+// Log files are named like glog's by default. Options.LogFileNameTemplate can
+// choose a different naming convention from fields like {name}, {level},
+// {time} and {seq}, and the same template is used to find the log files to
+// reuse or remove.
+//
+// # Log Formats
+//
+// Log lines use the glog text format by default. Options.Formatter can select
+// a different Formatter, like JSONFormatter or LogfmtFormatter, while the log
+// files are still organized and rotated the same way.
+//
+// # Sampling
+//
+// Options.Sampling limits the number of log messages written from each log
+// statement, for example the first 10 in every second and then every 100th.
+// VModules can have their own sampling policies, like SampleEvery or
+// SampleOnce, with SetVModuleSampling. Number of dropped log messages is
+// reported with the "suppressed" attribute in the next log message written
+// from the same log statement.
+//
+// # Repeated Messages
+//
+// Options.LogDedupTimeout collapses consecutive identical log messages, with
+// the same source location, message and attributes, into a single
+// "previous message repeated N times" summary line that records the first and
+// last repeat times. Summary is written when a different message arrives, the
+// log file is rotated, the backend is flushed or the timeout expires.
+//
+// # Redaction
+//
+// Options.RedactRules replace sensitive attribute values with a mask or a keyed
+// hash before they are written anywhere. Rules can match attributes by key,
+// by key glob, by dotted group path like "request.header.token" or by value
+// regular expression.
+//
+// # Standard Error
+//
+// Similar to glog's -logtostderr, -alsologtostderr and -stderrthreshold flags,
+// log messages can be written only to the standard error, to both the log
+// files and the standard error, or copied to the standard error only at or
+// above a threshold level. Standard error receives the same formatted log
+// lines as the log files.
+//
+// # Log File Retention
+//
+// Old log files can be removed automatically by age, by number of files per
+// log level, and by total size of the log directory. Retention limits are
+// enforced when the backend is created and whenever a log file is rotated.
+// Only the log files with the backend's program name, host and user are
+// considered, so files created by other programs are never removed.
+//
+// # Free Disk Space
+//
+// Options.LogDirMinFreeSpace guards against full disks. Log directories with
+// less free space are skipped for new log files, so logging falls over to the
+// next log directory and returns when space is freed. When all log directories
+// are low on space, the backend drops DEBUG and INFO log messages but keeps
+// WARN and higher, till space is freed.
+//
+// # Asynchronous Writes
+//
+// By default, log messages are written to the log files by the logging
+// goroutine itself. When Options.LogQueueSize is set, log messages are queued
+// and written by a background goroutine instead, so that slow disks do not
+// block the application. Options.LogQueuePolicy selects whether a full queue
+// blocks the logging goroutine or drops the newest or oldest messages.
+//
+// Backend.Close must be called before the program exits to write all queued
+// messages and to sync and close the log files.
+//
+// # Metrics
+//
+// Backend.Stats reports the number of log messages per level, bytes written,
+// log file rotations, write errors, truncated messages and messages dropped by
+// the write queue or by sampling. Counters can be published as an expvar
+// variable with Backend.PublishExpvar or served in the Prometheus text format
+// by MetricsHandler.
+//
+// # Errors and Health
+//
+// Failures to open, write, rotate, compress or remove the log files do not
+// fail the logging calls. They are printed to the standard error by default,
+// or passed to Options.ErrorHandler with their kind, level and path.
+// Backend.Health reports whether each log file is currently writable and its
+// last error, which can be used by readiness probes.
+//
+// # Recent Log Lines
+//
+// Options.RecentLogSize keeps the last log lines of each level in memory.
+// They are available through Backend.Recent, and RecentLogsHandler serves them
+// over HTTP, typically at /debug/logs, with filtering by level, substring and
+// vmodule, so recent errors can be inspected without access to the log files.
+//
+// # Fatal Messages
+//
+// The Fatal and FatalContext functions log a message at LevelFatal, followed by
+// the stack traces of all goroutines, to the FATAL log file and all lower level
+// log files. Log files are synced and the process is terminated with SIGABRT,
+// similar to glog.
+//
+// # Testing
+//
+// The sglogtest package captures the log records in memory for the tests to
+// assert on their levels, messages, attributes and vmodules, and creates
+// backends that write their log files in the test's temporary directory.
 //
 // # VModule Usage
 //
@@ -39,4 +158,30 @@
 //	var network = sglog.VModule("network", slog.LevelDebug)
 //	...
 //	slog.With(network).Info("Network event", ...)
+//
+// Modules are registered by name in a process-wide registry, so operators can
+// list them with VModules and change their levels by name or glob pattern with
+// SetVModules or ApplyVModuleSpec, like "network=debug,storage=error". Calling
+// VModule again with a registered name returns the existing module and ignores
+// the level argument.
+//
+// Log levels can also be selected by the source file of the log statements,
+// similar to glog's -vmodule flag, using Backend.SetFileVModule with a spec
+// like "server*=2,rpc/*=debug".
+//
+// AdminHandler serves an HTTP endpoint to inspect and change all of these log
+// levels at runtime, optionally reverting the changes after a timeout.
+//
+// Log levels can also be lowered for a single request with WithLevel, which
+// attaches a log level to a context. Log messages written with the context, as
+// in slog.DebugContext, are enabled at that level everywhere, while the rest of
+// the process logs at its usual levels.
+//
+// # Context Attributes
+//
+// Options.ContextExtractors add attributes from the context to every log
+// message written with a context, so request and trace ids need not be added
+// with slog.With everywhere. By default, W3C traceparent values stored in the
+// context with WithTraceParent are logged as the trace_id and span_id
+// attributes.
 package sglog
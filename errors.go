@@ -0,0 +1,176 @@
+package sglog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorKind identifies the operation that failed in a LogError.
+type ErrorKind int
+
+const (
+	// ErrorOpen is a failure to create, open or lock a log file.
+	ErrorOpen ErrorKind = iota
+
+	// ErrorWrite is a failure to write a log message to a log file.
+	ErrorWrite
+
+	// ErrorRotate is a failure to rotate a log file.
+	ErrorRotate
+
+	// ErrorSymlink is a failure to update a log file symlink.
+	ErrorSymlink
+
+	// ErrorClose is a failure to sync or close a log file.
+	ErrorClose
+
+	// ErrorCompress is a failure to compress a rotated log file.
+	ErrorCompress
+
+	// ErrorRemove is a failure to remove an old log file.
+	ErrorRemove
+
+	// ErrorDiskSpace reports that all log directories are low on free disk
+	// space and the backend is in the degraded mode.
+	ErrorDiskSpace
+
+	// ErrorRevert is a failure to revert the log levels changed temporarily
+	// with the AdminHandler.
+	ErrorRevert
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorOpen:
+		return "open"
+	case ErrorWrite:
+		return "write"
+	case ErrorRotate:
+		return "rotate"
+	case ErrorSymlink:
+		return "symlink"
+	case ErrorClose:
+		return "close"
+	case ErrorCompress:
+		return "compress"
+	case ErrorRemove:
+		return "remove"
+	case ErrorDiskSpace:
+		return "disk space"
+	case ErrorRevert:
+		return "revert"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// LogError describes a failure in the backend's log file operations.
+type LogError struct {
+	Kind ErrorKind
+
+	// Level is the name of the log file's level, or empty if the error is not
+	// specific to a level.
+	Level string
+
+	// Path is the log file or directory path, if known.
+	Path string
+
+	Time time.Time
+
+	Err error
+}
+
+func (e *LogError) Error() string {
+	s := "sglog: " + e.Kind.String() + " error"
+	if e.Level != "" {
+		s += " for level " + e.Level
+	}
+	if e.Path != "" {
+		s += fmt.Sprintf(" on %q", e.Path)
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *LogError) Unwrap() error {
+	return e.Err
+}
+
+// Health reports the state of the log files.
+type Health struct {
+	// Healthy is true if all log files are healthy.
+	Healthy bool
+
+	// Degraded is true if all log directories are low on free disk space, so
+	// DEBUG and INFO log messages are dropped.
+	Degraded bool
+
+	// Levels holds the state of each log level's log file, by level name.
+	Levels map[string]LevelHealth
+}
+
+// LevelHealth reports the state of the log file for a log level.
+type LevelHealth struct {
+	// Healthy is false if the last attempt to write to the log file failed.
+	Healthy bool
+
+	// Path is the current log file path, if a log file is open.
+	Path string
+
+	// LastError is the last error for the log file, if any, even if the log
+	// file has recovered since.
+	LastError *LogError
+}
+
+// healthState holds the error state of a level file.
+type healthState struct {
+	failing atomic.Bool
+
+	mu      sync.Mutex
+	lastErr *LogError
+}
+
+// reportError records the error and passes it to the error handler, which
+// prints it to the standard error by default. Level file is nil for the
+// errors that are not specific to a log level.
+func (v *Backend) reportError(kind ErrorKind, f *levelFile, fpath string, err error) {
+	e := &LogError{Kind: kind, Path: fpath, Time: time.Now(), Err: err}
+	if f != nil {
+		e.Level = f.levelName()
+		f.health.mu.Lock()
+		f.health.lastErr = e
+		f.health.mu.Unlock()
+	}
+
+	if v.opts.ErrorHandler != nil {
+		v.opts.ErrorHandler(e)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%v (ignored)\n", e)
+}
+
+// Health reports whether the log files can be written, along with the last
+// error for each log level. It can be used in readiness probes.
+func (v *Backend) Health() *Health {
+	files := v.LogFiles()
+
+	h := &Health{
+		Healthy:  true,
+		Degraded: v.disk.Load().degraded(),
+		Levels:   make(map[string]LevelHealth),
+	}
+	for l, f := range v.fileMap {
+		f.health.mu.Lock()
+		lh := LevelHealth{
+			Healthy:   !f.health.failing.Load(),
+			Path:      files[l],
+			LastError: f.health.lastErr,
+		}
+		f.health.mu.Unlock()
+
+		h.Levels[f.levelName()] = lh
+		h.Healthy = h.Healthy && lh.Healthy
+	}
+	return h
+}
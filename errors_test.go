@@ -0,0 +1,65 @@
+package sglog
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	var mu sync.Mutex
+	var errs []*LogError
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	backend := NewBackend(&Options{
+		Name:    "errors",
+		LogDirs: []string{missing, t.TempDir()},
+		ErrorHandler: func(err *LogError) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	defer backend.Close()
+
+	logger := slog.New(backend.Handler())
+	logger.Info("info message")
+	logger.Warn("warning message")
+
+	// Log files are created in the fallback directory.
+	health := backend.Health()
+	if !health.Healthy {
+		t.Fatalf("want healthy backend, got %+v", health)
+	}
+	lh := health.Levels["INFO"]
+	if lh.LastError == nil || lh.LastError.Kind != ErrorOpen || lh.LastError.Path != missing {
+		t.Fatalf("want open error for the missing directory, got %+v", lh.LastError)
+	}
+	if !errors.Is(lh.LastError, os.ErrNotExist) {
+		t.Fatalf("want os.ErrNotExist, got %v", lh.LastError)
+	}
+
+	// Closing the log file makes the next write fail.
+	backend.mu.Lock()
+	backend.fileMap[slog.LevelWarn].file.Close()
+	backend.mu.Unlock()
+
+	logger.Warn("warning message")
+
+	health = backend.Health()
+	if health.Healthy || health.Levels["WARN"].Healthy || !health.Levels["INFO"].Healthy {
+		t.Fatalf("want only the WARN log file unhealthy, got %+v", health)
+	}
+	if err := health.Levels["WARN"].LastError; err == nil || err.Kind != ErrorWrite {
+		t.Fatalf("want write error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) == 0 {
+		t.Fatalf("want errors reported to the error handler")
+	}
+}
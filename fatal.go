@@ -0,0 +1,110 @@
+package sglog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// LevelFatal is the log level for the fatal log messages. Log messages at this
+// level are written to the FATAL log file (and all lower level log files) along
+// with the stack traces of all goroutines.
+const LevelFatal = slog.Level(12)
+
+// levelName returns the log level name used in the log file names.
+func levelName(l slog.Level) string {
+	if l == LevelFatal {
+		return "FATAL"
+	}
+	return l.String()
+}
+
+// Fatal logs a message at LevelFatal with the default logger, and terminates
+// the process with SIGABRT after the log files are synced. Fatal never
+// returns.
+func Fatal(msg string, args ...any) {
+	fatal(context.Background(), msg, args...)
+}
+
+// FatalContext is like Fatal, but passes the context to the log handler.
+func FatalContext(ctx context.Context, msg string, args ...any) {
+	fatal(ctx, msg, args...)
+}
+
+func fatal(ctx context.Context, msg string, args ...any) {
+	logger := slog.Default()
+	if logger.Enabled(ctx, LevelFatal) {
+		var pcs [1]uintptr
+		runtime.Callers(3, pcs[:]) // skip [Callers, fatal, Fatal*]
+		r := slog.NewRecord(time.Now(), LevelFatal, msg, pcs[0])
+		r.Add(args...)
+		_ = logger.Handler().Handle(ctx, r)
+	}
+
+	err := abortProcess() // Should not return.
+	// Failed to abort the process using signals. Dump a stack trace and exit.
+	fmt.Fprintf(os.Stderr, "abortProcess returned unexpectedly: %v\n", err)
+	os.Stderr.Write(stacks(true))
+	os.Exit(2) // Exit with the same code as the default SIGABRT handler.
+}
+
+// stacksKey is the attribute key for the stack traces of FATAL log messages
+// with the structured formatters.
+const stacksKey = "stacks"
+
+// handleFatal writes the fatal log message followed by the stack traces of all
+// goroutines to all log files and syncs the log files. With the text
+// formatter, stack traces follow the log line as in glog; other formatters
+// get them in the "stacks" attribute, so that the log lines remain valid. The
+// stack traces are not truncated to the maximum message length.
+func (h *slogHandler) handleFatal(buf *bytes.Buffer, r slog.Record, e *logEntry) error {
+	if _, ok := h.backend.opts.Formatter.(TextFormatter); ok {
+		buf.WriteByte('\n')
+		buf.Write(stacks(true))
+		buf.WriteByte('\n')
+	} else {
+		r = r.Clone()
+		r.AddAttrs(slog.String(stacksKey, string(stacks(true))))
+		buf.Reset()
+		h.backend.opts.Formatter.Format(buf, r)
+		if b := buf.Bytes(); len(b) == 0 || b[len(b)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+
+	e.minLevel, e.msg, e.dedupKey = slog.LevelDebug, buf.Bytes(), ""
+
+	// Queued log messages are written before the fatal log message, which
+	// itself is written synchronously.
+	h.backend.Flush()
+	err := h.backend.emit(e)
+	if ferr := h.backend.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// stacks is a wrapper for runtime.Stack that attempts to recover the data for
+// all goroutines or the calling one.
+func stacks(all bool) []byte {
+	// We don't know how big the traces are, so grow a few times if they don't
+	// fit. Start large, though.
+	n := 10000
+	if all {
+		n = 100000
+	}
+	var trace []byte
+	for i := 0; i < 10; i++ {
+		trace = make([]byte, n)
+		nbytes := runtime.Stack(trace, all)
+		if nbytes < len(trace) {
+			return trace[:nbytes]
+		}
+		n *= 2
+	}
+	return trace
+}
@@ -0,0 +1,101 @@
+package sglog
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"log/slog"
+)
+
+func TestFatal(t *testing.T) {
+	if dir := os.Getenv("SGLOG_TEST_FATAL_DIR"); dir != "" {
+		policy, _ := strconv.Atoi(os.Getenv("SGLOG_TEST_FATAL_POLICY"))
+		opts := &Options{
+			Name:           "fatal",
+			LogDirs:        []string{dir},
+			LogQueueSize:   16,
+			LogQueuePolicy: QueuePolicy(policy),
+		}
+		if os.Getenv("SGLOG_TEST_FATAL_FORMAT") == "json" {
+			opts.Formatter = JSONFormatter{}
+		}
+		backend := NewBackend(opts)
+		slog.SetDefault(slog.New(backend.Handler()))
+		slog.Info("info message before fatal")
+
+		// Keep the queue full, so that the drop policies are in effect.
+		for i := 0; i < 4; i++ {
+			go func() {
+				for {
+					slog.Info("filler message")
+				}
+			}()
+		}
+		for i := 0; i < 1000; i++ {
+			slog.Info("filler message")
+		}
+		Fatal("fatal message", "key", "value")
+		return
+	}
+
+	for _, policy := range []QueuePolicy{QueueBlock, QueueDropNewest, QueueDropOldest} {
+		dir := t.TempDir()
+		cmd := exec.Command(os.Args[0], "-test.run=^TestFatal$")
+		cmd.Env = append(os.Environ(), "SGLOG_TEST_FATAL_DIR="+dir, "SGLOG_TEST_FATAL_POLICY="+strconv.Itoa(int(policy)))
+		if err := cmd.Run(); err == nil {
+			t.Fatalf("process must be aborted by Fatal")
+		}
+		checkFatalFiles(t, dir)
+	}
+}
+
+func TestFatalJSON(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatal$")
+	cmd.Env = append(os.Environ(), "SGLOG_TEST_FATAL_DIR="+dir, "SGLOG_TEST_FATAL_FORMAT=json")
+	if err := cmd.Run(); err == nil {
+		t.Fatalf("process must be aborted by Fatal")
+	}
+
+	for _, level := range []string{"INFO", "WARN", "ERROR", "FATAL"} {
+		var found bool
+		for _, line := range readLines(t, filepath.Join(dir, "fatal."+level)) {
+			var m map[string]any
+			if err := json.Unmarshal([]byte(line), &m); err != nil {
+				t.Fatalf("%s log file has invalid json line %q: %v", level, line, err)
+			}
+			if m["msg"] != "fatal message" {
+				continue
+			}
+			found = true
+			if s, _ := m["stacks"].(string); !strings.Contains(s, "goroutine ") {
+				t.Errorf("%s log file has no stack traces in the fatal message", level)
+			}
+		}
+		if !found {
+			t.Errorf("%s log file has no fatal message", level)
+		}
+	}
+}
+
+func checkFatalFiles(t *testing.T, dir string) {
+	t.Helper()
+
+	for _, level := range []string{"INFO", "WARN", "ERROR", "FATAL"} {
+		data, err := os.ReadFile(filepath.Join(dir, "fatal."+level))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `] fatal message key="value"`) {
+			t.Errorf("%s log file has no fatal message", level)
+		}
+		if !strings.Contains(string(data), "goroutine ") {
+			t.Errorf("%s log file has no stack traces", level)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package sglog
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultFileNameTemplate is the default log file name template, which is
+// compatible with glog.
+const DefaultFileNameTemplate = "{name}.{host}.{user}.log.{level}.{time}.{pid}"
+
+// fileTimeLayout is the time format for the {time} file name template field.
+const fileTimeLayout = "20060102-150405"
+
+// fileNameFields are the fields supported in the log file name templates.
+var fileNameFields = []string{"{name}", "{host}", "{user}", "{level}", "{time}", "{pid}", "{seq}"}
+
+// parseFileNameTemplate splits the log file name template into literal text
+// and fields. Templates must have the {level} and {time} fields, so that log
+// files of different levels have different names and their creation times
+// can be parsed back from the names.
+func parseFileNameTemplate(tmpl string) ([]string, error) {
+	var parts []string
+	for s := tmpl; s != ""; {
+		i := strings.IndexByte(s, '{')
+		if i < 0 {
+			parts = append(parts, s)
+			break
+		}
+		if i > 0 {
+			parts = append(parts, s[:i])
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			return nil, fmt.Errorf("unterminated field in log file name template %q", tmpl)
+		}
+		field := s[i : i+j+1]
+		if !slices.Contains(fileNameFields, field) {
+			return nil, fmt.Errorf("unknown field %s in log file name template %q", field, tmpl)
+		}
+		parts = append(parts, field)
+		s = s[i+j+1:]
+	}
+	if !slices.Contains(parts, "{level}") || !slices.Contains(parts, "{time}") {
+		return nil, fmt.Errorf("log file name template %q must have {level} and {time} fields", tmpl)
+	}
+	if strings.ContainsAny(tmpl, `/\`) {
+		return nil, fmt.Errorf("log file name template %q must not have path separators", tmpl)
+	}
+	return parts, nil
+}
+
+// setFileNameTemplate sets the log file name template of the backend from
+// the options. Invalid templates are reported on the standard error and the
+// default template is used instead. Level files must be created after the
+// template is set.
+func (v *Backend) setFileNameTemplate() {
+	tmpl, err := parseFileNameTemplate(v.opts.LogFileNameTemplate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid log file name template (ignored): %v\n", err)
+		tmpl, _ = parseFileNameTemplate(DefaultFileNameTemplate)
+	}
+	v.fileNameTemplate = tmpl
+}
+
+// fileNamePattern returns a regular expression that matches the log file
+// names of the level file, with the time and seq submatches. Files from all
+// processes match, so pid and seq fields match any number.
+func (f *levelFile) fileNamePattern() *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, p := range f.backend.fileNameTemplate {
+		switch p {
+		case "{name}":
+			sb.WriteString(regexp.QuoteMeta(f.backend.opts.Name))
+		case "{host}":
+			sb.WriteString(regexp.QuoteMeta(host))
+		case "{user}":
+			sb.WriteString(regexp.QuoteMeta(userName))
+		case "{level}":
+			sb.WriteString(regexp.QuoteMeta(f.levelName()))
+		case "{time}":
+			sb.WriteString(`(?P<time>\d{8}-\d{6})`)
+		case "{pid}":
+			sb.WriteString(`\d+`)
+		case "{seq}":
+			sb.WriteString(`(?P<seq>\d+)`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(p))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// fileName returns the log file name for a new log file created at the input
+// time. The {seq} field is the number of log files opened by the level file,
+// which keeps the names of the log files rotated in the same second unique.
+func (f *levelFile) fileName(t time.Time) string {
+	var sb strings.Builder
+	for _, p := range f.backend.fileNameTemplate {
+		switch p {
+		case "{name}":
+			sb.WriteString(f.backend.opts.Name)
+		case "{host}":
+			sb.WriteString(host)
+		case "{user}":
+			sb.WriteString(userName)
+		case "{level}":
+			sb.WriteString(f.levelName())
+		case "{time}":
+			sb.WriteString(t.Format(fileTimeLayout))
+		case "{pid}":
+			sb.WriteString(strconv.Itoa(pid))
+		case "{seq}":
+			sb.WriteString(strconv.Itoa(len(f.fpaths) + 1))
+		default:
+			sb.WriteString(p)
+		}
+	}
+	return sb.String()
+}
+
+// parseFileName returns the creation time and sequence number from the log
+// file name. Sequence number is zero if the template has no {seq} field.
+func (f *levelFile) parseFileName(name string) (time.Time, int, error) {
+	m := f.namePattern.FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}, 0, fmt.Errorf("%q is not a log file name for level %s: %w", name, f.levelName(), os.ErrInvalid)
+	}
+	ts, err := time.ParseInLocation(fileTimeLayout, m[f.namePattern.SubexpIndex("time")], time.Local)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	var seq int
+	if i := f.namePattern.SubexpIndex("seq"); i >= 0 {
+		if seq, err = strconv.Atoi(m[i]); err != nil {
+			return time.Time{}, 0, err
+		}
+	}
+	return ts, seq, nil
+}
+
+// FileName holds the fields parsed from a log file name. Fields missing in
+// the log file name template are left empty.
+type FileName struct {
+	Name  string
+	Host  string
+	User  string
+	Level string
+	Time  time.Time
+	Pid   int
+	Seq   int
+}
+
+// FileNameParser parses the log file names created with a log file name
+// template, like the -log_file_name flag.
+type FileNameParser struct {
+	re *regexp.Regexp
+}
+
+// NewFileNameParser returns a parser for the log file names created with the
+// input log file name template.
+func NewFileNameParser(tmpl string) (*FileNameParser, error) {
+	parts, err := parseFileNameTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	// Program names can have any characters, but host and user names are
+	// matched as short as possible, so that program names with dots are
+	// parsed back with the default template.
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, p := range parts {
+		switch p {
+		case "{name}":
+			sb.WriteString(`(?P<name>.+)`)
+		case "{host}":
+			sb.WriteString(`(?P<host>.+?)`)
+		case "{user}":
+			sb.WriteString(`(?P<user>.+?)`)
+		case "{level}":
+			sb.WriteString(`(?P<level>[A-Z]+)`)
+		case "{time}":
+			sb.WriteString(`(?P<time>\d{8}-\d{6})`)
+		case "{pid}":
+			sb.WriteString(`(?P<pid>\d+)`)
+		case "{seq}":
+			sb.WriteString(`(?P<seq>\d+)`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(p))
+		}
+	}
+	sb.WriteString("$")
+	return &FileNameParser{re: regexp.MustCompile(sb.String())}, nil
+}
+
+// Parse parses the log file name, which must not have a directory or a
+// compression suffix.
+func (p *FileNameParser) Parse(name string) (*FileName, error) {
+	m := p.re.FindStringSubmatch(name)
+	if m == nil {
+		return nil, fmt.Errorf("%q is not a log file name: %w", name, os.ErrInvalid)
+	}
+	field := func(s string) string {
+		if i := p.re.SubexpIndex(s); i >= 0 {
+			return m[i]
+		}
+		return ""
+	}
+
+	v := &FileName{
+		Name:  field("name"),
+		Host:  field("host"),
+		User:  field("user"),
+		Level: field("level"),
+	}
+	ts, err := time.ParseInLocation(fileTimeLayout, field("time"), time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%q has invalid time: %w", name, err)
+	}
+	v.Time = ts
+	if s := field("pid"); s != "" {
+		if v.Pid, err = strconv.Atoi(s); err != nil {
+			return nil, fmt.Errorf("%q has invalid pid: %w", name, err)
+		}
+	}
+	if s := field("seq"); s != "" {
+		if v.Seq, err = strconv.Atoi(s); err != nil {
+			return nil, fmt.Errorf("%q has invalid seq: %w", name, err)
+		}
+	}
+	return v, nil
+}
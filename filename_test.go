@@ -0,0 +1,126 @@
+package sglog
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseFileNameTemplate(t *testing.T) {
+	testcases := []struct {
+		tmpl  string
+		valid bool
+	}{
+		{DefaultFileNameTemplate, true},
+		{"{name}-{level}-{time}-{seq}.log", true},
+		{"{level}{time}", true},
+		{"{name}.{level}.log", false},
+		{"{name}.{time}.log", false},
+		{"{name}.{level}.{time}.{date}", false},
+		{"{name}.{level}.{time", false},
+		{"logs/{name}.{level}.{time}", false},
+	}
+
+	for i, tc := range testcases {
+		if _, err := parseFileNameTemplate(tc.tmpl); tc.valid != (err == nil) {
+			t.Errorf("%d: parseFileNameTemplate(%q) returned %v", i, tc.tmpl, err)
+		}
+	}
+}
+
+func TestFileNameTemplate(t *testing.T) {
+	dir := t.TempDir()
+	opts := &Options{
+		Name:                "my.service",
+		LogDirs:             []string{dir},
+		LogFileNameTemplate: "{name}_{level}_{time}_{pid}_{seq}.log",
+		LogFileMaxSize:      100,
+	}
+	backend := NewBackend(opts)
+
+	f := backend.fileMap[slog.LevelInfo]
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+	name := f.fileName(now)
+	if want := "my.service_INFO_20240506-070809_" + strconv.Itoa(pid) + "_1.log"; name != want {
+		t.Fatalf("want file name %q, got %q", want, name)
+	}
+	ts, seq, err := f.parseFileName("my.service_INFO_20240506-070809_99_7.log")
+	if err != nil || !ts.Equal(now) || seq != 7 {
+		t.Fatalf("unexpected parse result %v %d %v", ts, seq, err)
+	}
+	for _, other := range []string{"my.service_WARN_20240506-070809_99_7.log", "myxservice_INFO_20240506-070809_99_7.log", "my.service_INFO_20240506-070809_99_7.log.gz"} {
+		if _, err := f.fileTime(other); err == nil {
+			t.Errorf("%q must not match the INFO log file names", other)
+		}
+	}
+
+	// Log files rotated in the same second must have different names.
+	logger := slog.New(backend.Handler())
+	for i := 0; i < 5; i++ {
+		logger.Info("info message that is long enough to rotate the log file")
+	}
+	backend.Close()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "my.service_INFO_*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) < 5 {
+		t.Fatalf("want at least 5 log files, got %q", matches)
+	}
+
+	// Log file with the latest time and sequence number is reused.
+	opts.LogFileMaxSize = 1 << 20
+	opts.LogDirs = []string{dir}
+	backend = NewBackend(opts)
+	defer backend.Close()
+
+	last, err := backend.fileMap[slog.LevelInfo].lastFileName(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(backend.Handler()).Info("info message")
+	if path := backend.LogFiles()[slog.LevelInfo]; path != filepath.Join(dir, last) {
+		t.Fatalf("want reused log file %q, got %q", last, path)
+	}
+}
+
+func TestFileNameParser(t *testing.T) {
+	testcases := []struct {
+		tmpl, name string
+		want       FileName
+	}{
+		{DefaultFileNameTemplate, "my.server.host.user.log.WARN.20240307-134510.1234",
+			FileName{Name: "my.server", Host: "host", User: "user", Level: "WARN", Time: time.Date(2024, 3, 7, 13, 45, 10, 0, time.Local), Pid: 1234}},
+		{"{name}_{level}_{time}_{pid}_{seq}.log", "my.service_INFO_20240506-070809_99_7.log",
+			FileName{Name: "my.service", Level: "INFO", Time: time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local), Pid: 99, Seq: 7}},
+	}
+	for i, tc := range testcases {
+		p, err := NewFileNameParser(tc.tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := p.Parse(tc.name)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if *got != tc.want {
+			t.Errorf("%d: want %+v, got %+v", i, tc.want, *got)
+		}
+	}
+
+	p, err := NewFileNameParser(DefaultFileNameTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"server.INFO", "server.host.user.txt.INFO.20240307-134510.1234", "server.host.user.log.INFO.2024.1234"} {
+		if _, err := p.Parse(name); err == nil {
+			t.Errorf("%q must not be parsed as a log file name", name)
+		}
+	}
+	if _, err := NewFileNameParser("{name}.log"); err == nil {
+		t.Errorf("invalid template must fail")
+	}
+}
@@ -0,0 +1,157 @@
+package sglog
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fileVModule holds the source file based vmodule rules, similar to glog's
+// -vmodule flag.
+type fileVModule struct {
+	spec string
+
+	rules []fileRule
+
+	// minLevel is the lowest log level enabled by any rule.
+	minLevel slog.Level
+
+	// cache holds the rule decisions for program counters, so that source
+	// files are matched only once for every log statement.
+	cache sync.Map // map[uintptr]fileMatch
+}
+
+type fileRule struct {
+	pattern string
+	level   slog.Level
+
+	// ncomponents is the number of path components in the pattern. Patterns
+	// with slashes are matched against as many trailing components of the
+	// source file path.
+	ncomponents int
+}
+
+type fileMatch struct {
+	level slog.Level
+	ok    bool
+}
+
+// parseFileVModule parses a glog style vmodule spec, which is a comma
+// separated list of pattern=level pairs.
+func parseFileVModule(spec string) (*fileVModule, error) {
+	v := &fileVModule{spec: spec}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(item, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("vmodule item %q is not in pattern=level form", item)
+		}
+		pattern = strings.TrimSuffix(pattern, ".go")
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("vmodule item %q has invalid pattern: %w", item, err)
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("vmodule item %q has invalid level: %w", item, err)
+		}
+		if len(v.rules) == 0 || level < v.minLevel {
+			v.minLevel = level
+		}
+		v.rules = append(v.rules, fileRule{
+			pattern:     pattern,
+			level:       level,
+			ncomponents: strings.Count(pattern, "/") + 1,
+		})
+	}
+	return v, nil
+}
+
+// level returns the log level from the first rule that matches the source
+// file of the program counter.
+func (v *fileVModule) level(pc uintptr) (slog.Level, bool) {
+	if m, ok := v.cache.Load(pc); ok {
+		return m.(fileMatch).level, m.(fileMatch).ok
+	}
+
+	var m fileMatch
+	if pc != 0 {
+		fs := runtime.CallersFrames([]uintptr{pc})
+		f, _ := fs.Next()
+		file := strings.TrimSuffix(filepath.ToSlash(f.File), ".go")
+		for _, rule := range v.rules {
+			if rule.match(file) {
+				m = fileMatch{level: rule.level, ok: true}
+				break
+			}
+		}
+	}
+	v.cache.Store(pc, m)
+	return m.level, m.ok
+}
+
+func (r *fileRule) match(file string) bool {
+	// Take as many trailing path components as the pattern has.
+	components := strings.Split(file, "/")
+	if len(components) > r.ncomponents {
+		components = components[len(components)-r.ncomponents:]
+	}
+	ok, _ := filepath.Match(r.pattern, strings.Join(components, "/"))
+	return ok
+}
+
+// SetFileVModule sets the source file based vmodule rules from a glog
+// compatible -vmodule spec, like "server*=2,rpc/*=debug". Patterns are glob
+// patterns matched against the source file names of the log statements
+// without the ".go" suffix. Patterns with slashes are matched against as many
+// trailing components of the source file paths. Levels are log level names or
+// glog style verbosity numbers as accepted by ParseLevel. First matching
+// rule's level replaces the backend's default log level for the log
+// statement. Empty spec removes all rules.
+func (v *Backend) SetFileVModule(spec string) error {
+	fv, err := parseFileVModule(spec)
+	if err != nil {
+		return err
+	}
+	if len(fv.rules) == 0 {
+		fv = nil
+	}
+	v.fileVModule.Store(fv)
+	return nil
+}
+
+// FileVModule returns the current source file based vmodule spec.
+func (v *Backend) FileVModule() string {
+	if fv := v.fileVModule.Load(); fv != nil {
+		return fv.spec
+	}
+	return ""
+}
+
+// ParseLevel parses a log level name, like debug, info, warn, warning, error,
+// fatal or their offsets like info+2, or a glog style verbosity number. A
+// verbosity of N is the log level N steps below the info level, so verbosity
+// 4 is the debug level.
+func ParseLevel(s string) (slog.Level, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return slog.LevelInfo - slog.Level(n), nil
+	}
+	switch strings.ToUpper(s) {
+	case "FATAL":
+		return LevelFatal, nil
+	case "WARNING":
+		return slog.LevelWarn, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, err
+	}
+	return l, nil
+}
@@ -0,0 +1,79 @@
+package sglog
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileRuleMatch(t *testing.T) {
+	testcases := []struct {
+		spec string
+		file string
+		want bool
+	}{
+		{"server=1", "/src/app/server", true},
+		{"server*=1", "/src/app/server_test", true},
+		{"server=1", "/src/app/client", false},
+		{"app/*=1", "/src/app/server", true},
+		{"rpc/*=1", "/src/app/server", false},
+		{"src/*/server=1", "/src/app/server", true},
+		{"*/*/*/*/server=1", "/src/app/server", false},
+	}
+	for _, tc := range testcases {
+		fv, err := parseFileVModule(tc.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fv.rules[0].match(tc.file); got != tc.want {
+			t.Errorf("spec %q file %q: got %t, want %t", tc.spec, tc.file, got, tc.want)
+		}
+	}
+
+	for _, spec := range []string{"server", "=1", "server=high", "[=1"} {
+		if _, err := parseFileVModule(spec); err == nil {
+			t.Errorf("spec %q must be invalid", spec)
+		}
+	}
+}
+
+func TestFileVModule(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:    "filevmodule",
+		LogDirs: []string{dir},
+	})
+	logger := slog.New(backend.Handler())
+
+	logger.Debug("debug message before vmodule")
+	if err := backend.SetFileVModule("filevmodule_test=4"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("debug message with vmodule")
+	if err := backend.SetFileVModule("other*=debug,filevmodule*=error"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Warn("warn message with vmodule")
+	if err := backend.SetFileVModule(""); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("debug message after vmodule")
+	logger.Warn("warn message after vmodule")
+	backend.Close()
+
+	debug := strings.Join(readLines(t, filepath.Join(dir, "filevmodule.DEBUG")), "\n")
+	if !strings.Contains(debug, "debug message with vmodule") {
+		t.Errorf("debug message from matching source file is not logged")
+	}
+	for _, msg := range []string{"before vmodule", "after vmodule"} {
+		if strings.Contains(debug, msg) {
+			t.Errorf("debug message %q must not be logged", msg)
+		}
+	}
+
+	warn := strings.Join(readLines(t, filepath.Join(dir, "filevmodule.WARN")), "\n")
+	if strings.Contains(warn, "warn message with vmodule") || !strings.Contains(warn, "warn message after vmodule") {
+		t.Errorf("unexpected warn log file content %q", warn)
+	}
+}
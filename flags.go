@@ -0,0 +1,324 @@
+package sglog
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flags holds the values of the glog compatible command-line flags registered
+// by RegisterFlags.
+type Flags struct {
+	name string
+
+	logDirs    string
+	logLinkDir string
+
+	fileNameTemplate string
+
+	maxLogSize  logSizeValue
+	maxDirSize  sizeValue
+	maxFileAge  durationValue
+	maxFiles    int
+	reuse       durationValue
+	rotate      durationValue
+	rotateUTC   bool
+	compress    bool
+	header      bool
+	maxLen      int
+	queueSize   int
+	format      string
+	level       levelValue
+	fileVModule fileVModuleValue
+	vmodules    string
+
+	logToStderr     bool
+	alsoLogToStderr bool
+	stderrThreshold severityValue
+}
+
+// RegisterFlags registers the glog compatible logging flags, like -log_dir,
+// -log_link, -v, -vmodule and -max_log_size, along with the flags for other
+// sglog options on the flag set. Use the Options, Level and FileVModule
+// methods, or the NewBackend method, after the flags are parsed.
+//
+// Sizes accept optional units like KB, MB, GB (powers of 1000) and KiB, MiB,
+// GiB (powers of 1024), for example "512MiB". Like glog, -max_log_size is in
+// MiB when the unit is missing. Durations accept time.Duration strings and a
+// number of days with the "d" suffix, like "7d". Like glog, -stderrthreshold
+// accepts the severity numbers 0 to 3 for INFO, WARN, ERROR and FATAL.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{
+		level:           levelValue(slog.LevelInfo),
+		stderrThreshold: severityValue(slog.LevelError),
+	}
+
+	fs.StringVar(&f.name, "log_name", "", "program name for the log files (default is the binary name)")
+	fs.StringVar(&f.logDirs, "log_dir", "", "comma separated list of directories for the log files (default is the temporary directory)")
+	fs.StringVar(&f.logLinkDir, "log_link", "", "if non-empty, directory for the symbolic links to the log files")
+	fs.StringVar(&f.fileNameTemplate, "log_file_name", DefaultFileNameTemplate, "log file name template with {name}, {host}, {user}, {level}, {time}, {pid} and {seq} fields")
+	fs.Var(&f.maxLogSize, "max_log_size", "maximum size of a log file before it is rotated, like 512MiB (in MiB without a unit)")
+	fs.Var(&f.reuse, "log_file_reuse", "maximum duration to reuse an existing log file at startup")
+	fs.Var(&f.rotate, "log_rotate_interval", "if non-zero, rotate log files at wall-clock aligned intervals, like 1h or 1d")
+	fs.BoolVar(&f.rotateUTC, "log_rotate_utc", false, "align log file rotation intervals in UTC instead of local time")
+	fs.BoolVar(&f.compress, "log_compress", false, "compress rotated log files with gzip")
+	fs.Var(&f.maxFileAge, "log_max_age", "if non-zero, remove log files older than this duration, like 30d")
+	fs.IntVar(&f.maxFiles, "log_max_files", 0, "if non-zero, maximum number of log files to keep for each level")
+	fs.Var(&f.maxDirSize, "log_dir_max_size", "if non-zero, maximum total size of log files in a log directory, like 10GiB")
+	fs.BoolVar(&f.header, "log_file_header", false, "write a header at the start of each log file")
+	fs.IntVar(&f.maxLen, "log_message_max_len", 0, "maximum length of a formatted log message (default 15000)")
+	fs.IntVar(&f.queueSize, "log_queue_size", 0, "if non-zero, write log messages asynchronously with a queue of this size")
+	fs.StringVar(&f.format, "log_format", "text", "log line format: text, json or logfmt")
+	fs.Var(&f.level, "v", "log level as a glog verbosity number (like 2) or level name (like debug)")
+	fs.Var(&f.fileVModule, "vmodule", "comma separated list of pattern=level source file vmodule rules, like server*=2,rpc/*=debug")
+	fs.BoolVar(&f.logToStderr, "logtostderr", false, "log to standard error instead of files")
+	fs.BoolVar(&f.alsoLogToStderr, "alsologtostderr", false, "log to standard error as well as files")
+	fs.Var(&f.stderrThreshold, "stderrthreshold", "log messages at or above this level name or glog severity number (0 to 3) are copied to standard error")
+	fs.StringVar(&f.vmodules, "vmodules", "", "comma separated list of name=level settings for registered vmodules, like network=debug")
+	return f
+}
+
+// Options returns the backend options from the flag values.
+func (f *Flags) Options() (*Options, error) {
+	opts := &Options{
+		Name:                  f.name,
+		LogLinkDir:            f.logLinkDir,
+		LogFileNameTemplate:   f.fileNameTemplate,
+		LogFileMaxSize:        uint64(f.maxLogSize),
+		LogFileHeader:         f.header,
+		LogFileReuseDuration:  time.Duration(f.reuse),
+		LogFileRotateInterval: time.Duration(f.rotate),
+		LogFileRotateUTC:      f.rotateUTC,
+		LogFileCompress:       f.compress,
+		LogFileMaxAge:         time.Duration(f.maxFileAge),
+		LogFileMaxCount:       f.maxFiles,
+		LogDirMaxSize:         uint64(f.maxDirSize),
+		LogMessageMaxLen:      f.maxLen,
+		LogQueueSize:          f.queueSize,
+		LogToStderr:           f.logToStderr,
+		AlsoLogToStderr:       f.alsoLogToStderr,
+		StderrThreshold:       slog.Level(f.stderrThreshold),
+	}
+	for _, dir := range strings.Split(f.logDirs, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			opts.LogDirs = append(opts.LogDirs, dir)
+		}
+	}
+	if opts.LogFileNameTemplate != "" {
+		if _, err := parseFileNameTemplate(opts.LogFileNameTemplate); err != nil {
+			return nil, err
+		}
+	}
+	switch f.format {
+	case "", "text":
+		opts.Formatter = TextFormatter{}
+	case "json":
+		opts.Formatter = JSONFormatter{}
+	case "logfmt":
+		opts.Formatter = LogfmtFormatter{}
+	default:
+		return nil, fmt.Errorf("unknown log format %q", f.format)
+	}
+	return opts, nil
+}
+
+// Level returns the initial log level from the -v flag.
+func (f *Flags) Level() slog.Level {
+	return slog.Level(f.level)
+}
+
+// FileVModule returns the source file vmodule spec from the -vmodule flag.
+func (f *Flags) FileVModule() string {
+	return string(f.fileVModule)
+}
+
+// VModules returns the registered vmodules spec from the -vmodules flag.
+func (f *Flags) VModules() string {
+	return f.vmodules
+}
+
+// NewBackend creates a backend with the options from the flag values, and
+// applies the initial log level and vmodule specs.
+func (f *Flags) NewBackend() (*Backend, error) {
+	opts, err := f.Options()
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyVModuleSpec(f.vmodules); err != nil {
+		return nil, err
+	}
+	backend := NewBackend(opts)
+	backend.SetLevel(f.Level())
+	if err := backend.SetFileVModule(f.FileVModule()); err != nil {
+		backend.Close()
+		return nil, err
+	}
+	return backend, nil
+}
+
+// ParseSize parses a size in bytes with an optional unit suffix. Units KB,
+// MB, GB and TB are powers of 1000 and KiB, MiB, GiB and TiB are powers of
+// 1024. Units are case-insensitive, and K, M, G and T are the same as KiB,
+// MiB, GiB and TiB for compatibility with common tools.
+func ParseSize(s string) (uint64, error) {
+	units := []struct {
+		suffix string
+		scale  float64
+	}{
+		{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40},
+		{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9}, {"tb", 1e12},
+		{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30}, {"t", 1 << 40},
+		{"b", 1},
+	}
+
+	num, scale := strings.ToLower(strings.TrimSpace(s)), 1.0
+	for _, u := range units {
+		if v, ok := strings.CutSuffix(num, u.suffix); ok {
+			num, scale = strings.TrimSpace(v), u.scale
+			break
+		}
+	}
+	if n, err := strconv.ParseUint(num, 10, 64); err == nil && scale == 1 {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || f*scale >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(f * scale), nil
+}
+
+// parseDuration parses a time.Duration string, or a number of days with the
+// "d" suffix.
+func parseDuration(s string) (time.Duration, error) {
+	if v, ok := strings.CutSuffix(s, "d"); ok {
+		days, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+type sizeValue uint64
+
+func (v *sizeValue) String() string {
+	if *v == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*v), 10)
+}
+
+func (v *sizeValue) Set(s string) error {
+	n, err := ParseSize(s)
+	if err != nil {
+		return err
+	}
+	*v = sizeValue(n)
+	return nil
+}
+
+// logSizeValue is a size flag value in MiB when the unit is missing, like the
+// glog -max_log_size flag.
+type logSizeValue uint64
+
+func (v *logSizeValue) String() string {
+	if *v == 0 {
+		return ""
+	}
+	if *v%(1<<20) == 0 {
+		return strconv.FormatUint(uint64(*v>>20), 10)
+	}
+	return strconv.FormatUint(uint64(*v), 10) + "B"
+}
+
+func (v *logSizeValue) Set(s string) error {
+	num := strings.TrimSpace(s)
+	if num != "" && num[len(num)-1] >= '0' && num[len(num)-1] <= '9' {
+		num += "MiB"
+	}
+	n, err := ParseSize(num)
+	if err != nil {
+		return fmt.Errorf("invalid size %q", s)
+	}
+	*v = logSizeValue(n)
+	return nil
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string {
+	if *v == 0 {
+		return ""
+	}
+	return time.Duration(*v).String()
+}
+
+func (v *durationValue) Set(s string) error {
+	d, err := parseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+type levelValue slog.Level
+
+func (v *levelValue) String() string {
+	return slog.Level(*v).String()
+}
+
+func (v *levelValue) Set(s string) error {
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	*v = levelValue(l)
+	return nil
+}
+
+// severityValue is a level flag value that accepts the glog severity numbers,
+// where 0, 1, 2 and 3 are INFO, WARN, ERROR and FATAL, along with the level
+// names.
+type severityValue slog.Level
+
+func (v *severityValue) String() string {
+	return slog.Level(*v).String()
+}
+
+func (v *severityValue) Set(s string) error {
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+		severities := []slog.Level{slog.LevelInfo, slog.LevelWarn, slog.LevelError, LevelFatal}
+		if n < 0 || n >= len(severities) {
+			return fmt.Errorf("invalid severity %q", s)
+		}
+		*v = severityValue(severities[n])
+		return nil
+	}
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	*v = severityValue(l)
+	return nil
+}
+
+type fileVModuleValue string
+
+func (v *fileVModuleValue) String() string {
+	return string(*v)
+}
+
+func (v *fileVModuleValue) Set(s string) error {
+	if _, err := parseFileVModule(s); err != nil {
+		return err
+	}
+	*v = fileVModuleValue(s)
+	return nil
+}
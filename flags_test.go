@@ -0,0 +1,118 @@
+package sglog
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	testcases := []struct {
+		in   string
+		want uint64
+	}{
+		{"1024", 1024},
+		{"512MiB", 512 << 20},
+		{"512mib", 512 << 20},
+		{"1.5GiB", 3 << 29},
+		{"2KB", 2000},
+		{"10 MB", 10e6},
+		{"4k", 4096},
+		{"100B", 100},
+	}
+	for _, tc := range testcases {
+		got, err := ParseSize(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "MiB", "-1MB", "1XB", "1e30TiB"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) must fail", in)
+		}
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+	args := []string{
+		"-log_dir", "/var/log/a,/var/log/b",
+		"-log_link", "/var/log/links",
+		"-max_log_size", "512MiB",
+		"-log_file_reuse", "1d",
+		"-log_max_age", "30d",
+		"-log_format", "json",
+		"-v", "2",
+		"-vmodule", "server*=4,rpc/*=debug",
+	}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := flags.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.LogDirs) != 2 || opts.LogDirs[1] != "/var/log/b" || opts.LogLinkDir != "/var/log/links" {
+		t.Errorf("unexpected log directories in %+v", opts)
+	}
+	if opts.LogFileMaxSize != 512<<20 || opts.LogFileReuseDuration != 24*time.Hour || opts.LogFileMaxAge != 30*24*time.Hour {
+		t.Errorf("unexpected log file limits in %+v", opts)
+	}
+	if _, ok := opts.Formatter.(JSONFormatter); !ok {
+		t.Errorf("unexpected formatter %T", opts.Formatter)
+	}
+	if flags.Level() != slog.LevelInfo-2 || flags.FileVModule() != "server*=4,rpc/*=debug" {
+		t.Errorf("unexpected level %v or vmodule %q", flags.Level(), flags.FileVModule())
+	}
+
+	for _, args := range [][]string{{"-v", "loud"}, {"-vmodule", "server"}, {"-max_log_size", "big"}} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		RegisterFlags(fs)
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("flags %q must fail", args)
+		}
+	}
+}
+
+func TestGlogFlags(t *testing.T) {
+	testcases := []struct {
+		args      []string
+		maxSize   uint64
+		threshold slog.Level
+	}{
+		{[]string{"-max_log_size", "1800"}, 1800 << 20, slog.LevelError},
+		{[]string{"-max_log_size=1.5", "-stderrthreshold=0"}, 3 << 19, slog.LevelInfo},
+		{[]string{"-max_log_size", "100B", "-stderrthreshold", "1"}, 100, slog.LevelWarn},
+		{[]string{"-stderrthreshold", "2"}, 0, slog.LevelError},
+		{[]string{"-stderrthreshold", "3"}, 0, LevelFatal},
+		{[]string{"-stderrthreshold", "WARNING"}, 0, slog.LevelWarn},
+	}
+	for _, tc := range testcases {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := RegisterFlags(fs)
+		if err := fs.Parse(tc.args); err != nil {
+			t.Fatalf("flags %q: %v", tc.args, err)
+		}
+		opts, err := flags.Options()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.LogFileMaxSize != tc.maxSize || opts.StderrThreshold != tc.threshold {
+			t.Errorf("flags %q: want size %d and threshold %v, got %d and %v", tc.args, tc.maxSize, tc.threshold, opts.LogFileMaxSize, opts.StderrThreshold)
+		}
+	}
+
+	for _, args := range [][]string{{"-stderrthreshold", "4"}, {"-stderrthreshold", "-1"}, {"-max_log_size", "-1"}} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		RegisterFlags(fs)
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("flags %q must fail", args)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package sglog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatter formats log records into log lines.
+//
+// Attributes of the log record passed to the formatter include the attributes
+// added with slog.Logger.With, wrapped in slog.Group attributes for the groups
+// added with slog.Logger.WithGroup. A trailing newline is added by the backend
+// when the formatter doesn't write one.
+type Formatter interface {
+	Format(buf *bytes.Buffer, r slog.Record)
+}
+
+// JSONFormatter formats the log records as JSON objects, one per line. Groups
+// are formatted as nested JSON objects.
+//
+//	{"time":"...","level":"INFO","pid":1234,"source":"file.go:12","msg":"...","key":"value"}
+type JSONFormatter struct{}
+
+// Format implements the Formatter interface.
+func (JSONFormatter) Format(buf *bytes.Buffer, r slog.Record) {
+	buf.WriteString(`{"time":`)
+	appendJSONString(buf, r.Time.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	appendJSONString(buf, levelName(r.Level))
+	buf.WriteString(`,"pid":`)
+	buf.WriteString(strconv.Itoa(pid))
+	buf.WriteString(`,"source":`)
+	file, line := recordSource(r)
+	appendJSONString(buf, file+":"+strconv.Itoa(line))
+	buf.WriteString(`,"msg":`)
+	appendJSONString(buf, r.Message)
+
+	r.Attrs(func(a slog.Attr) bool {
+		appendJSONAttr(buf, a)
+		return true
+	})
+	buf.WriteString("}\n")
+}
+
+func appendJSONAttr(buf *bytes.Buffer, a slog.Attr) {
+	// Resolve the Attr's value before doing anything else.
+	a.Value = a.Value.Resolve()
+	// Ignore empty Attrs.
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		// Ignore empty groups.
+		if len(attrs) == 0 {
+			return
+		}
+		// Inline the attributes of groups with empty keys.
+		if a.Key == "" {
+			for _, ga := range attrs {
+				appendJSONAttr(buf, ga)
+			}
+			return
+		}
+		buf.WriteByte(',')
+		appendJSONString(buf, a.Key)
+		buf.WriteString(":{")
+		mark := buf.Len()
+		for _, ga := range attrs {
+			appendJSONAttr(buf, ga)
+		}
+		// Remove the leading comma from the first attribute in the group.
+		if b := buf.Bytes(); buf.Len() > mark && b[mark] == ',' {
+			copy(b[mark:], b[mark+1:])
+			buf.Truncate(buf.Len() - 1)
+		}
+		buf.WriteByte('}')
+		return
+	}
+
+	buf.WriteByte(',')
+	appendJSONString(buf, a.Key)
+	buf.WriteByte(':')
+	appendJSONValue(buf, a.Value)
+}
+
+func appendJSONValue(buf *bytes.Buffer, v slog.Value) {
+	var tmp [32]byte
+	switch v.Kind() {
+	case slog.KindString:
+		appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		buf.Write(strconv.AppendInt(tmp[:0], v.Int64(), 10))
+	case slog.KindUint64:
+		buf.Write(strconv.AppendUint(tmp[:0], v.Uint64(), 10))
+	case slog.KindFloat64:
+		// JSON has no representation for NaN and infinities.
+		if f := v.Float64(); math.IsNaN(f) || math.IsInf(f, 0) {
+			appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+		} else {
+			buf.Write(strconv.AppendFloat(tmp[:0], f, 'g', -1, 64))
+		}
+	case slog.KindBool:
+		buf.Write(strconv.AppendBool(tmp[:0], v.Bool()))
+	case slog.KindDuration:
+		buf.Write(strconv.AppendInt(tmp[:0], int64(v.Duration()), 10))
+	case slog.KindTime:
+		appendJSONString(buf, v.Time().Format(time.RFC3339Nano))
+	default:
+		x := v.Any()
+		if _, ok := x.(json.Marshaler); !ok {
+			if err, ok := x.(error); ok {
+				appendJSONString(buf, err.Error())
+				return
+			}
+		}
+		var jbuf bytes.Buffer
+		enc := json.NewEncoder(&jbuf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(x); err != nil {
+			appendJSONString(buf, fmt.Sprintf("%+v", x))
+			return
+		}
+		buf.Write(bytes.TrimRight(jbuf.Bytes(), "\n"))
+	}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString writes the string as a quoted JSON string. Invalid UTF-8
+// bytes are replaced with the Unicode replacement character.
+func appendJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			switch {
+			case b == '"' || b == '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case b == '\n':
+				buf.WriteString(`\n`)
+			case b == '\r':
+				buf.WriteString(`\r`)
+			case b == '\t':
+				buf.WriteString(`\t`)
+			case b < 0x20:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[b>>4])
+				buf.WriteByte(hexDigits[b&0xF])
+			default:
+				buf.WriteByte(b)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(`\ufffd`)
+		} else {
+			buf.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	buf.WriteByte('"')
+}
+
+// LogfmtFormatter formats the log records in the logfmt format. Group
+// attributes are prefixed with their group names separated by dots.
+//
+//	time=... level=INFO pid=1234 source=file.go:12 msg="..." key=value group.key=value
+type LogfmtFormatter struct{}
+
+// Format implements the Formatter interface.
+func (LogfmtFormatter) Format(buf *bytes.Buffer, r slog.Record) {
+	buf.WriteString("time=")
+	buf.WriteString(r.Time.Format(time.RFC3339Nano))
+	buf.WriteString(" level=")
+	buf.WriteString(levelName(r.Level))
+	buf.WriteString(" pid=")
+	buf.WriteString(strconv.Itoa(pid))
+	buf.WriteString(" source=")
+	file, line := recordSource(r)
+	appendLogfmtString(buf, file+":"+strconv.Itoa(line))
+	buf.WriteString(" msg=")
+	appendLogfmtString(buf, r.Message)
+
+	r.Attrs(func(a slog.Attr) bool {
+		appendLogfmtAttr(buf, a, "")
+		return true
+	})
+	buf.WriteByte('\n')
+}
+
+func appendLogfmtAttr(buf *bytes.Buffer, a slog.Attr, prefix string) {
+	// Resolve the Attr's value before doing anything else.
+	a.Value = a.Value.Resolve()
+	// Ignore empty Attrs.
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, ga := range attrs {
+			appendLogfmtAttr(buf, ga, prefix)
+		}
+		return
+
+	case slog.KindTime:
+		buf.WriteByte(' ')
+		appendLogfmtString(buf, prefix+a.Key)
+		buf.WriteByte('=')
+		buf.WriteString(a.Value.Time().Format(time.RFC3339Nano))
+
+	case slog.KindAny:
+		buf.WriteByte(' ')
+		appendLogfmtString(buf, prefix+a.Key)
+		buf.WriteByte('=')
+		if err, ok := a.Value.Any().(error); ok {
+			appendLogfmtString(buf, err.Error())
+		} else {
+			appendLogfmtString(buf, a.Value.String())
+		}
+
+	default:
+		buf.WriteByte(' ')
+		appendLogfmtString(buf, prefix+a.Key)
+		buf.WriteByte('=')
+		appendLogfmtString(buf, a.Value.String())
+	}
+}
+
+// appendLogfmtString writes the string as is, or quoted when it is empty or
+// contains spaces, quotes, equal signs or non-printable characters.
+func appendLogfmtString(buf *bytes.Buffer, s string) {
+	if s == "" {
+		buf.WriteString(`""`)
+		return
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !strconv.IsPrint(r) {
+			buf.WriteString(strconv.Quote(s))
+			return
+		}
+	}
+	buf.WriteString(s)
+}
@@ -0,0 +1,104 @@
+package sglog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readLines(t *testing.T, fpath string) []string {
+	t.Helper()
+	fp, err := os.Open(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	var lines []string
+	for s := bufio.NewScanner(fp); s.Scan(); {
+		lines = append(lines, s.Text())
+	}
+	return lines
+}
+
+func TestFormatters(t *testing.T) {
+	testcases := []struct {
+		formatter Formatter
+		want      string
+	}{
+		{TextFormatter{}, `] message vmodule="fmt" key="value" g1.g2.n=1 g1.g2.err=fail`},
+		{LogfmtFormatter{}, ` level=INFO pid=`},
+		{LogfmtFormatter{}, ` msg=message vmodule=fmt key=value g1.g2.n=1 g1.g2.err=fail`},
+		{JSONFormatter{}, `"msg":"message","vmodule":"fmt","key":"value","g1":{"g2":{"n":1,"err":"fail"}}}`},
+	}
+
+	for _, tc := range testcases {
+		dir := t.TempDir()
+		backend := NewBackend(&Options{
+			Name:      "format",
+			LogDirs:   []string{dir},
+			Formatter: tc.formatter,
+		})
+		logger := slog.New(backend.Handler()).With(VModule("fmt", slog.LevelInfo), "key", "value")
+		logger.WithGroup("g1").WithGroup("g2").Info("message", "n", 1, "err", errors.New("fail"))
+		backend.Close()
+
+		lines := readLines(t, filepath.Join(dir, "format.INFO"))
+		if len(lines) != 1 {
+			t.Fatalf("%T: want one line, got %q", tc.formatter, lines)
+		}
+		if !strings.Contains(lines[0], tc.want) {
+			t.Errorf("%T: line %q doesn't contain %q", tc.formatter, lines[0], tc.want)
+		}
+		if _, ok := tc.formatter.(JSONFormatter); ok {
+			var m map[string]any
+			if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+				t.Errorf("invalid json line %q: %v", lines[0], err)
+			}
+		}
+	}
+}
+
+func TestFormatTruncation(t *testing.T) {
+	long := strings.Repeat("x", 500)
+	for _, formatter := range []Formatter{TextFormatter{}, LogfmtFormatter{}, JSONFormatter{}} {
+		dir := t.TempDir()
+		backend := NewBackend(&Options{
+			Name:             "truncate",
+			LogDirs:          []string{dir},
+			Formatter:        formatter,
+			LogMessageMaxLen: 250,
+		})
+		logger := slog.New(backend.Handler())
+		logger.Info("message "+long, "key", "value with \"quotes\" "+long, slog.Group("g", "n", 1, "err", errors.New(long)))
+		backend.Close()
+
+		lines := readLines(t, filepath.Join(dir, "truncate.INFO"))
+		if len(lines) != 1 {
+			t.Fatalf("%T: want one line, got %q", formatter, lines)
+		}
+		line := lines[0]
+		if len(line)+1 > 250 {
+			t.Errorf("%T: line with %d bytes is longer than the limit", formatter, len(line)+1)
+		}
+		if !strings.Contains(line, "xx...") || !strings.Contains(line, "n=1") && !strings.Contains(line, `"n":1`) {
+			t.Errorf("%T: unexpected truncated line %q", formatter, line)
+		}
+		switch formatter.(type) {
+		case JSONFormatter:
+			var m map[string]any
+			if err := json.Unmarshal([]byte(line), &m); err != nil {
+				t.Errorf("invalid json line %q: %v", line, err)
+			}
+		case LogfmtFormatter, TextFormatter:
+			if strings.Count(line, `"`)-strings.Count(line, `\"`) != 2*strings.Count(line, `="`) {
+				t.Errorf("%T: unbalanced quotes in line %q", formatter, line)
+			}
+		}
+	}
+}
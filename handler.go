@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,10 +16,11 @@ import (
 // NOTE: Most of the following code is copied from the example
 // slog-handler-guide.
 
-// groupOrAttrs holds either a group name or a list of slog.Attrs.
-type groupOrAttrs struct {
-	group string      // group name if non-empty
-	attrs []slog.Attr // attrs if non-empty
+// GroupOrAttrs holds either a group name or a list of slog.Attrs, added to a
+// slog.Handler with its WithGroup or WithAttrs methods.
+type GroupOrAttrs struct {
+	Group string      // group name if non-empty
+	Attrs []slog.Attr // attrs if non-empty
 }
 
 type slogHandler struct {
@@ -28,7 +30,7 @@ type slogHandler struct {
 
 	backend *Backend
 
-	goas []groupOrAttrs
+	goas []GroupOrAttrs
 }
 
 func (v *Backend) newHandler(opts *Options) *slogHandler {
@@ -38,9 +40,9 @@ func (v *Backend) newHandler(opts *Options) *slogHandler {
 	}
 }
 
-func (h *slogHandler) withGroupOrAttrs(goa groupOrAttrs) *slogHandler {
+func (h *slogHandler) withGroupOrAttrs(goa GroupOrAttrs) *slogHandler {
 	h2 := *h
-	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
+	h2.goas = make([]GroupOrAttrs, len(h.goas)+1)
 	copy(h2.goas, h.goas)
 	h2.goas[len(h2.goas)-1] = goa
 	return &h2
@@ -51,7 +53,7 @@ func (h *slogHandler) WithGroup(name string) slog.Handler {
 	if name == "" {
 		return h
 	}
-	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+	return h.withGroupOrAttrs(GroupOrAttrs{Group: name})
 }
 
 // WithAttrs implements the WithAttrs method for slog.Handler interface.
@@ -59,13 +61,30 @@ func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	if len(attrs) == 0 {
 		return h
 	}
-	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+	return h.withGroupOrAttrs(GroupOrAttrs{Attrs: attrs})
 }
 
 func (h *slogHandler) minLevel() slog.Level {
+	return h.attrsMinLevel(h.backend.currentLevel.Level())
+}
+
+// recordMinLevel returns the minimum log level for a log statement, which
+// includes the source file based vmodule rules.
+func (h *slogHandler) recordMinLevel(pc uintptr) slog.Level {
 	level := h.backend.currentLevel.Level()
+	if fv := h.backend.fileVModule.Load(); fv != nil {
+		if l, ok := fv.level(pc); ok {
+			level = l
+		}
+	}
+	return h.attrsMinLevel(level)
+}
+
+// attrsMinLevel returns the minimum of the input level and the vmodule
+// attribute levels.
+func (h *slogHandler) attrsMinLevel(level slog.Level) slog.Level {
 	for _, goa := range h.goas {
-		for _, attr := range goa.attrs {
+		for _, attr := range goa.Attrs {
 			if current, ok := VModuleLevel(attr); ok {
 				level = min(level, current)
 			}
@@ -76,11 +95,35 @@ func (h *slogHandler) minLevel() slog.Level {
 
 // Enabled implements the Enabled method for slog.Handler interface.
 func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level >= h.minLevel()
+	if level >= LevelFatal || level >= h.minLevel() {
+		return true
+	}
+	if l, ok := ContextLevel(ctx); ok && level >= l {
+		return true
+	}
+	// Source file is not known here, so the source file based vmodule rules
+	// are checked in Handle.
+	if fv := h.backend.fileVModule.Load(); fv != nil {
+		return level >= fv.minLevel
+	}
+	return false
 }
 
 // Handle implements the Handle method for slog.Handler interface.
 func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	minLevel := h.recordMinLevel(r.PC)
+	if l, ok := ContextLevel(ctx); ok {
+		minLevel = min(minLevel, l)
+	}
+	if r.Level < minLevel && r.Level < LevelFatal {
+		return nil
+	}
+	if h.backend.dropLowDisk(r.Level) {
+		h.backend.countRecord(r.Level)
+		h.backend.stats.lowDiskDropped.Add(1)
+		return nil
+	}
+
 	bufi := bufs.Get()
 	var buf *bytes.Buffer
 	if bufi == nil {
@@ -92,14 +135,117 @@ func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
 	}
 	defer bufs.Put(bufi)
 
-	h.format(ctx, buf, r)
-	return h.backend.emit(h.minLevel(), r.Level, buf.Bytes())
+	var suppressed int
+	if sampling, vmodule := h.sampling(); sampling != nil && r.PC != 0 && r.Level < LevelFatal {
+		var ok bool
+		if ok, suppressed = h.backend.sample(r.PC, vmodule, sampling, r.Time); !ok {
+			h.backend.stats.suppressed.Add(1)
+			return nil
+		}
+	}
+
+	var extra []slog.Attr
+	if ctx != nil {
+		for _, x := range h.backend.opts.ContextExtractors {
+			extra = append(extra, x.Extract(ctx)...)
+		}
+	}
+	if suppressed > 0 {
+		extra = append(extra, slog.Int(suppressedKey, suppressed))
+	}
+
+	rec := h.backend.redact(BuildRecord(r, h.goas, extra...))
+	h.backend.format(buf, rec)
+
+	e := &logEntry{
+		minLevel: minLevel,
+		maxLevel: r.Level,
+		msg:      buf.Bytes(),
+		pc:       r.PC,
+		time:     r.Time,
+	}
+	if h.backend.recent != nil {
+		e.vmodule = vmoduleName(rec)
+	}
+	if h.backend.opts.LogDedupTimeout > 0 {
+		e.dedupKey = dedupKey(rec)
+	}
+	h.backend.countRecord(r.Level)
+	if r.Level >= LevelFatal {
+		return h.handleFatal(buf, rec, e)
+	}
+	return h.backend.emit(e)
 }
 
 // bufs is a pool of *bytes.Buffer used in formatting log entries.
 var bufs sync.Pool // Pool of *bytes.Buffer.
 
-func (h *slogHandler) format(ctx context.Context, buf *bytes.Buffer, r slog.Record) {
+// format formats the log record with the configured formatter, truncating it
+// to the maximum message length and adding the trailing newline. Long log
+// records are truncated by shortening their message and attribute values
+// before they are formatted, so that the structured formats remain valid.
+// Buffer must be empty.
+func (v *Backend) format(buf *bytes.Buffer, r slog.Record) {
+	max := v.opts.LogMessageMaxLen - 1
+	v.opts.Formatter.Format(buf, r)
+	if lineLen(buf) > max {
+		v.stats.truncated.Add(1)
+		v.formatShort(buf, r, max)
+	}
+	if b := buf.Bytes(); len(b) == 0 || b[len(b)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+}
+
+// BuildRecord returns a copy of the log record with the groups and attributes
+// from WithGroup and WithAttrs, in the order they were added to the handler,
+// included in its attributes. Groups are represented as slog.Group attributes,
+// so that formatters can handle all attributes the same way. Extra attributes
+// are added at the end, outside of all groups. Handlers wrapping the sglog
+// formatters can use it to lay out the records the same way as the backend.
+func BuildRecord(r slog.Record, goas []GroupOrAttrs, extra ...slog.Attr) slog.Record {
+	if r.NumAttrs() == 0 {
+		// If the record has no Attrs, remove groups at the end of the list; they are empty.
+		for len(goas) > 0 && goas[len(goas)-1].Group != "" {
+			goas = goas[:len(goas)-1]
+		}
+	}
+	if len(goas) == 0 && len(extra) == 0 {
+		return r
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for i := len(goas) - 1; i >= 0; i-- {
+		if goas[i].Group != "" {
+			attrs = []slog.Attr{{Key: goas[i].Group, Value: slog.GroupValue(attrs...)}}
+		} else {
+			attrs = append(slices.Clip(goas[i].Attrs), attrs...)
+		}
+	}
+
+	attrs = append(attrs, extra...)
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(attrs...)
+	return nr
+}
+
+// TextFormatter formats the log records in the glog text format. It is the
+// default formatter.
+//
+// Log lines have the following format, followed by the log message and its
+// attributes as key=value pairs. String attribute values are quoted and group
+// attributes are prefixed with their group names separated by dots.
+//
+//	Lmmdd hh:mm:ss.uuuuuu PID file:line] msg key="value" group.key=value
+type TextFormatter struct{}
+
+// Format implements the Formatter interface.
+func (TextFormatter) Format(buf *bytes.Buffer, r slog.Record) {
 
 	// Lmmdd hh:mm:ss.uuuuuu PID/GID file:line]
 	//
@@ -111,6 +257,8 @@ func (h *slogHandler) format(ctx context.Context, buf *bytes.Buffer, r slog.Reco
 	// It's worth about 3X. Fprintf is hard.
 
 	switch {
+	case r.Level >= LevelFatal:
+		buf.WriteByte(byte('F'))
 	case r.Level >= slog.LevelError:
 		buf.WriteByte(byte('E'))
 	case r.Level >= slog.LevelWarn:
@@ -136,19 +284,8 @@ func (h *slogHandler) format(ctx context.Context, buf *bytes.Buffer, r slog.Reco
 	nDigits(buf, 7, uint64(pid), ' ')
 	buf.WriteByte(' ')
 
-	file, line := "unknownfile.go", 0
-	if r.PC != 0 {
-		fs := runtime.CallersFrames([]uintptr{r.PC})
-		f, _ := fs.Next()
-		file, line = f.File, f.Line
-	}
-
-	{
-		if i := strings.LastIndex(file, "/"); i >= 0 {
-			file = file[i+1:]
-		}
-		buf.WriteString(file)
-	}
+	file, line := recordSource(r)
+	buf.WriteString(file)
 
 	buf.WriteByte(':')
 	{
@@ -159,37 +296,25 @@ func (h *slogHandler) format(ctx context.Context, buf *bytes.Buffer, r slog.Reco
 
 	buf.WriteString(r.Message)
 
-	// Handle state from WithGroup and WithAttrs.
-	goas := h.goas
-	if r.NumAttrs() == 0 {
-		// If the record has no Attrs, remove groups at the end of the list; they are empty.
-		for len(goas) > 0 && goas[len(goas)-1].group != "" {
-			goas = goas[:len(goas)-1]
-		}
-	}
-
-	prefix := ""
-	for _, goa := range goas {
-		if goa.group != "" {
-			prefix = fmt.Sprintf("%s.", goa.group)
-		} else {
-			for _, a := range goa.attrs {
-				h.appendAttr(buf, a, prefix)
-			}
-		}
-	}
 	r.Attrs(func(a slog.Attr) bool {
-		h.appendAttr(buf, a, prefix)
+		appendTextAttr(buf, a, "")
 		return true
 	})
+}
 
-	if buf.Len() > h.backend.opts.LogMessageMaxLen-1 {
-		buf.Truncate(h.backend.opts.LogMessageMaxLen - 1)
+// recordSource returns the source file base name and line number for the log
+// record.
+func recordSource(r slog.Record) (string, int) {
+	file, line := "unknownfile.go", 0
+	if r.PC != 0 {
+		fs := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := fs.Next()
+		file, line = f.File, f.Line
 	}
-	if b := buf.Bytes(); b[len(b)-1] != '\n' {
-		buf.WriteByte('\n')
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		file = file[i+1:]
 	}
-	// fmt.Fprintf(os.Stderr, "%s", buf.Bytes())
+	return file, line
 }
 
 const digits = "0123456789"
@@ -218,7 +343,7 @@ func nDigits(buf *bytes.Buffer, n int, d uint64, pad byte) {
 	buf.Write(tmp[j:])
 }
 
-func (h *slogHandler) appendAttr(buf *bytes.Buffer, a slog.Attr, prefix string) {
+func appendTextAttr(buf *bytes.Buffer, a slog.Attr, prefix string) {
 	// Resolve the Attr's value before doing anything else.
 	a.Value = a.Value.Resolve()
 	// Ignore empty Attrs.
@@ -245,7 +370,7 @@ func (h *slogHandler) appendAttr(buf *bytes.Buffer, a slog.Attr, prefix string)
 			prefix = fmt.Sprintf("%s%s.", prefix, a.Key)
 		}
 		for _, ga := range attrs {
-			h.appendAttr(buf, ga, prefix)
+			appendTextAttr(buf, ga, prefix)
 		}
 
 	default:
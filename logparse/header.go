@@ -0,0 +1,58 @@
+package logparse
+
+import (
+	"strings"
+	"time"
+)
+
+// Header represents a log file header line written when a log file is
+// created or reopened.
+type Header struct {
+	// Key is the header name, like "Log file created at".
+	Key string
+
+	// Value is the header value.
+	Value string
+}
+
+// Header keys written by the sglog package.
+const (
+	HeaderCreatedAt  = "Log file created at"
+	HeaderReopenedAt = "Log file is reopened at"
+	HeaderMachine    = "Running on machine"
+	HeaderBinary     = "Binary"
+	HeaderPrevious   = "Previous log"
+	HeaderLineFormat = "Log line format"
+)
+
+var headerKeys = []string{
+	HeaderCreatedAt,
+	HeaderReopenedAt,
+	HeaderMachine,
+	HeaderBinary,
+	HeaderPrevious,
+	HeaderLineFormat,
+}
+
+// ParseHeader parses a log file header line. Returns false if the input is
+// not a header line.
+func ParseHeader(line string) (*Header, bool) {
+	for _, key := range headerKeys {
+		if v, ok := strings.CutPrefix(line, key+": "); ok {
+			return &Header{Key: key, Value: v}, true
+		}
+	}
+	return nil, false
+}
+
+// Time returns the time from the log file created or reopened header lines.
+func (h *Header) Time(loc *time.Location) (time.Time, bool) {
+	if h.Key != HeaderCreatedAt && h.Key != HeaderReopenedAt {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006/01/02 15:04:05", h.Value, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,235 @@
+// Package logparse parses the log lines written by the sglog package in the
+// default glog text format.
+//
+// Log lines have the following format, where the message is followed by the
+// log attributes as key=value pairs. String attribute values are quoted and
+// attributes in groups have their keys prefixed with the group names separated
+// by dots.
+//
+//	Lmmdd hh:mm:ss.uuuuuu PID file:line] msg key="value" group.key=value
+//
+// Log messages are not quoted, so a message that contains a " key=" like
+// sequence is indistinguishable from the attributes. Parser ends the message
+// at the first space that is followed by a key and an equal sign. Similarly,
+// unquoted attribute values (like errors) can contain spaces, so an unquoted
+// attribute value ends only at the next " key=" sequence.
+//
+// Log files can also contain the header lines written when the log files are
+// created or reopened, which are parsed as Header values.
+package logparse
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LevelFatal is the log level for the FATAL log messages, which is the same as
+// sglog.LevelFatal.
+const LevelFatal = slog.Level(12)
+
+// ErrNotLogLine is returned when the input is not a log line.
+var ErrNotLogLine = errors.New("logparse: not a log line")
+
+// Record represents a parsed log line.
+type Record struct {
+	// Severity is the severity letter at the start of the log line.
+	Severity byte
+
+	// Level is the log level corresponding to the severity letter. Debug log
+	// messages use the same severity letter as info messages, so they cannot be
+	// distinguished.
+	Level slog.Level
+
+	// Time is the log message time. Log lines do not include the year, so it
+	// is taken from the parser.
+	Time time.Time
+
+	// PID is the process id that wrote the log message.
+	PID int
+
+	// File and Line are the source file base name and line number of the log
+	// statement.
+	File string
+	Line int
+
+	// Message is the log message.
+	Message string
+
+	// Attrs holds the log attributes in the order they were written.
+	Attrs []Attr
+
+	// Continuation holds the lines following the log line that are not log
+	// lines or headers, like the stack traces after a FATAL log message.
+	Continuation []string
+}
+
+// Attr represents a log attribute.
+type Attr struct {
+	// Key is the attribute key including the group names separated by dots.
+	Key string
+
+	// Value is the attribute value. Quoted values are unquoted.
+	Value string
+
+	// Quoted is true if the value was quoted, which is the case for string
+	// values.
+	Quoted bool
+}
+
+// Groups returns the group names from the attribute key.
+func (a Attr) Groups() []string {
+	fs := strings.Split(a.Key, ".")
+	return fs[:len(fs)-1]
+}
+
+// Name returns the attribute key without the group names.
+func (a Attr) Name() string {
+	if i := strings.LastIndexByte(a.Key, '.'); i >= 0 {
+		return a.Key[i+1:]
+	}
+	return a.Key
+}
+
+// Attr returns the value of the first attribute with the input key, which
+// must include the group names.
+func (r *Record) Attr(key string) (string, bool) {
+	for _, a := range r.Attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// Source returns the source location of the log statement as file:line.
+func (r *Record) Source() string {
+	return r.File + ":" + strconv.Itoa(r.Line)
+}
+
+// Parse parses a log line. Log line must not include the trailing newline.
+// Record time uses the current year and the local time zone.
+func Parse(line string) (*Record, error) {
+	return ParseInYear(line, time.Now().Year(), time.Local)
+}
+
+// ParseInYear parses a log line with the input year and time zone for the
+// record time.
+func ParseInYear(line string, year int, loc *time.Location) (*Record, error) {
+	// Lmmdd hh:mm:ss.uuuuuu PID file:line] msg
+	const prefixLen = len("Lmmdd hh:mm:ss.uuuuuu ")
+	if len(line) < prefixLen {
+		return nil, ErrNotLogLine
+	}
+
+	r := &Record{Severity: line[0]}
+	switch line[0] {
+	case 'I':
+		r.Level = slog.LevelInfo
+	case 'W':
+		r.Level = slog.LevelWarn
+	case 'E':
+		r.Level = slog.LevelError
+	case 'F':
+		r.Level = LevelFatal
+	default:
+		return nil, ErrNotLogLine
+	}
+
+	ts, err := time.ParseInLocation("0102 15:04:05.000000", line[1:prefixLen-1], loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid time: %v", ErrNotLogLine, err)
+	}
+	r.Time = time.Date(year, ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), loc)
+
+	rest := strings.TrimLeft(line[prefixLen:], " ")
+	pidStr, rest, ok := strings.Cut(rest, " ")
+	if !ok {
+		return nil, fmt.Errorf("%w: missing pid", ErrNotLogLine)
+	}
+	if r.PID, err = strconv.Atoi(pidStr); err != nil {
+		return nil, fmt.Errorf("%w: invalid pid: %v", ErrNotLogLine, err)
+	}
+
+	source, rest, ok := strings.Cut(rest, "] ")
+	if !ok {
+		// Empty messages without attributes have no trailing space.
+		if source, ok = strings.CutSuffix(rest, "]"); !ok {
+			return nil, fmt.Errorf("%w: missing source location", ErrNotLogLine)
+		}
+		rest = ""
+	}
+	i := strings.LastIndexByte(source, ':')
+	if i < 0 {
+		return nil, fmt.Errorf("%w: invalid source location", ErrNotLogLine)
+	}
+	r.File = source[:i]
+	if r.Line, err = strconv.Atoi(source[i+1:]); err != nil {
+		return nil, fmt.Errorf("%w: invalid source line: %v", ErrNotLogLine, err)
+	}
+
+	end := nextAttr(rest, 0)
+	r.Message = rest[:end]
+	r.Attrs = parseAttrs(rest[end:])
+	return r, nil
+}
+
+// nextAttr returns the index of the first space at or after the input
+// position that starts a " key=" sequence, or the length of the input.
+func nextAttr(s string, pos int) int {
+	for i := pos; i < len(s); i++ {
+		if s[i] == ' ' && keyLen(s[i+1:]) > 0 {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// keyLen returns the length of the attribute key at the start of the input if
+// it is followed by an equal sign, or zero.
+func keyLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '=':
+			return i
+		case ' ', '"':
+			return 0
+		}
+	}
+	return 0
+}
+
+// parseAttrs parses a sequence of " key=value" pairs.
+func parseAttrs(s string) []Attr {
+	var attrs []Attr
+	for len(s) > 0 {
+		// Input always starts with a space followed by a key.
+		n := keyLen(s[1:])
+		a := Attr{Key: s[1 : n+1]}
+		s = s[n+2:]
+
+		if strings.HasPrefix(s, `"`) {
+			if q, err := strconv.QuotedPrefix(s); err == nil {
+				if v, err := strconv.Unquote(q); err == nil {
+					a.Value, a.Quoted = v, true
+					s = s[len(q):]
+					// Quoted value must be followed by another attribute.
+					if end := nextAttr(s, 0); end == 0 {
+						attrs = append(attrs, a)
+						continue
+					}
+					s = q + s
+				}
+			}
+		}
+
+		end := nextAttr(s, 0)
+		a.Value, a.Quoted = s[:end], false
+		s = s[end:]
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
@@ -0,0 +1,105 @@
+package logparse_test
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/visvasity/sglog"
+	"github.com/visvasity/sglog/logparse"
+)
+
+func TestParse(t *testing.T) {
+	line := `W0307 13:45:10.123456    1234 server.go:42] request failed: timeout vmodule="network" err=dial tcp: i/o timeout req.id="a b\"c" req.n=7`
+	r, err := logparse.ParseInYear(line, 2024, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Severity != 'W' || r.Level != slog.LevelWarn {
+		t.Errorf("unexpected severity %c level %v", r.Severity, r.Level)
+	}
+	if want := time.Date(2024, 3, 7, 13, 45, 10, 123456000, time.UTC); !r.Time.Equal(want) {
+		t.Errorf("got time %v, want %v", r.Time, want)
+	}
+	if r.PID != 1234 || r.Source() != "server.go:42" {
+		t.Errorf("unexpected pid %d source %s", r.PID, r.Source())
+	}
+	if r.Message != "request failed: timeout" {
+		t.Errorf("unexpected message %q", r.Message)
+	}
+
+	want := []logparse.Attr{
+		{Key: "vmodule", Value: "network", Quoted: true},
+		{Key: "err", Value: "dial tcp: i/o timeout"},
+		{Key: "req.id", Value: `a b"c`, Quoted: true},
+		{Key: "req.n", Value: "7"},
+	}
+	if len(r.Attrs) != len(want) {
+		t.Fatalf("got attrs %+v, want %+v", r.Attrs, want)
+	}
+	for i := range want {
+		if r.Attrs[i] != want[i] {
+			t.Errorf("attr %d: got %+v, want %+v", i, r.Attrs[i], want[i])
+		}
+	}
+	if groups := r.Attrs[2].Groups(); len(groups) != 1 || groups[0] != "req" || r.Attrs[2].Name() != "id" {
+		t.Errorf("unexpected groups %q and name %q", groups, r.Attrs[2].Name())
+	}
+
+	if _, err := logparse.Parse("Running on machine: localhost"); !errors.Is(err, logparse.ErrNotLogLine) {
+		t.Errorf("header line must not parse as a log line: %v", err)
+	}
+	if h, ok := logparse.ParseHeader("Running on machine: localhost"); !ok || h.Key != logparse.HeaderMachine || h.Value != "localhost" {
+		t.Errorf("unexpected header %+v", h)
+	}
+}
+
+func TestScanLogFile(t *testing.T) {
+	dir := t.TempDir()
+	backend := sglog.NewBackend(&sglog.Options{
+		Name:          "logparse",
+		LogDirs:       []string{dir},
+		LogFileHeader: true,
+	})
+	logger := slog.New(backend.Handler())
+	logger.Info("first message", "key", "value")
+	logger.WithGroup("g").Warn("second message", "n", 1)
+	backend.Close()
+
+	fp, err := os.Open(filepath.Join(dir, "logparse.INFO"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	var headers []*logparse.Header
+	var records []*logparse.Record
+	for s := logparse.NewScanner(fp); s.Scan(); {
+		if h := s.Header(); h != nil {
+			headers = append(headers, h)
+		}
+		if r := s.Record(); r != nil {
+			records = append(records, r)
+		}
+	}
+
+	if len(headers) == 0 || headers[0].Key != logparse.HeaderCreatedAt {
+		t.Fatalf("unexpected headers %+v", headers)
+	}
+	if len(records) != 2 {
+		t.Fatalf("want two records, got %d", len(records))
+	}
+	if v, ok := records[0].Attr("key"); !ok || v != "value" || records[0].Message != "first message" {
+		t.Errorf("unexpected first record %+v", records[0])
+	}
+	if v, ok := records[1].Attr("g.n"); !ok || v != "1" || records[1].Level != slog.LevelWarn {
+		t.Errorf("unexpected second record %+v", records[1])
+	}
+	if records[0].PID != os.Getpid() || time.Since(records[0].Time) > time.Minute {
+		t.Errorf("unexpected pid or time in record %+v", records[0])
+	}
+}
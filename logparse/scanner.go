@@ -0,0 +1,138 @@
+package logparse
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// Scanner reads log records and header lines from a log file.
+//
+// Lines that are neither log lines nor header lines are added to the
+// preceding record as continuation lines. Record times use the year from the
+// most recent "Log file created at" or "Log file is reopened at" header, and
+// move into the next year when the month goes backwards.
+type Scanner struct {
+	// Year is the year for the record times. It is initialized to the current
+	// year and updated from the log file headers.
+	Year int
+
+	// Location is the time zone for the record and header times.
+	Location *time.Location
+
+	sc *bufio.Scanner
+
+	// next holds the lookahead line, if any.
+	next    string
+	hasNext bool
+
+	record *Record
+	header *Header
+	text   string
+
+	lastMonth time.Month
+}
+
+// maxLineSize is the maximum supported log line size.
+const maxLineSize = 1024 * 1024
+
+// NewScanner returns a Scanner to read from the input.
+func NewScanner(r io.Reader) *Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxLineSize)
+	return &Scanner{
+		Year:     time.Now().Year(),
+		Location: time.Local,
+		sc:       sc,
+	}
+}
+
+func (s *Scanner) readLine() (string, bool) {
+	if s.hasNext {
+		s.hasNext = false
+		return s.next, true
+	}
+	if !s.sc.Scan() {
+		return "", false
+	}
+	return s.sc.Text(), true
+}
+
+func (s *Scanner) unreadLine(line string) {
+	s.next, s.hasNext = line, true
+}
+
+// Scan advances to the next record or header line, which are available
+// through the Record or Header methods. Returns false at the end of input or
+// on an error.
+func (s *Scanner) Scan() bool {
+	s.record, s.header, s.text = nil, nil, ""
+
+	for {
+		line, ok := s.readLine()
+		if !ok {
+			return false
+		}
+
+		if h, ok := ParseHeader(line); ok {
+			if t, ok := h.Time(s.Location); ok {
+				s.Year, s.lastMonth = t.Year(), t.Month()
+			}
+			s.header, s.text = h, line
+			return true
+		}
+
+		r, err := ParseInYear(line, s.Year, s.Location)
+		if err != nil {
+			// Skip the unknown lines before the first record.
+			continue
+		}
+		if month := r.Time.Month(); month < s.lastMonth {
+			s.Year++
+			r.Time = r.Time.AddDate(1, 0, 0)
+		}
+		s.lastMonth = r.Time.Month()
+
+		for {
+			next, ok := s.readLine()
+			if !ok {
+				break
+			}
+			if _, ok := ParseHeader(next); ok {
+				s.unreadLine(next)
+				break
+			}
+			if _, err := ParseInYear(next, s.Year, s.Location); err == nil {
+				s.unreadLine(next)
+				break
+			}
+			r.Continuation = append(r.Continuation, next)
+		}
+
+		s.record, s.text = r, line
+		return true
+	}
+}
+
+// Record returns the most recent log record read by Scan, or nil if Scan has
+// read a header line.
+func (s *Scanner) Record() *Record {
+	return s.record
+}
+
+// Header returns the most recent header line read by Scan, or nil if Scan has
+// read a log record.
+func (s *Scanner) Header() *Header {
+	return s.header
+}
+
+// Text returns the most recent log line or header line read by Scan,
+// excluding the continuation lines.
+func (s *Scanner) Text() string {
+	return s.text
+}
+
+// Err returns the first non-EOF error encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.sc.Err()
+}
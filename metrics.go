@@ -0,0 +1,155 @@
+package sglog
+
+import (
+	"expvar"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// backendStats holds the backend counters.
+type backendStats struct {
+	writeErrors atomic.Uint64
+	truncated   atomic.Uint64
+	dropped     atomic.Uint64
+	suppressed  atomic.Uint64
+
+	lowDiskDropped atomic.Uint64
+}
+
+// fileStats holds the counters for a level file.
+type fileStats struct {
+	records     atomic.Uint64
+	bytes       atomic.Uint64
+	rotations   atomic.Uint64
+	writeErrors atomic.Uint64
+}
+
+// Stats is a snapshot of the backend counters.
+type Stats struct {
+	// Levels holds the counters for each log level, by level name.
+	Levels map[string]LevelStats `json:"levels"`
+
+	// WriteErrors is the number of log messages that could not be written to
+	// one or more log files.
+	WriteErrors uint64 `json:"write_errors"`
+
+	// Truncated is the number of log messages truncated to LogMessageMaxLen.
+	Truncated uint64 `json:"truncated"`
+
+	// Dropped is the number of log messages dropped because the asynchronous
+	// write queue is full.
+	Dropped uint64 `json:"dropped"`
+
+	// Suppressed is the number of log messages dropped by sampling.
+	Suppressed uint64 `json:"suppressed"`
+
+	// LowDiskDropped is the number of log messages dropped in the degraded
+	// mode because all log directories are low on free disk space.
+	LowDiskDropped uint64 `json:"low_disk_dropped"`
+}
+
+// LevelStats holds the counters for a log level and its log file.
+type LevelStats struct {
+	// Records is the number of log messages at this level.
+	Records uint64 `json:"records"`
+
+	// Bytes is the number of bytes written to the log files of this level,
+	// which includes the log messages from the higher levels.
+	Bytes uint64 `json:"bytes"`
+
+	// Rotations is the number of log file rotations.
+	Rotations uint64 `json:"rotations"`
+
+	// WriteErrors is the number of failed writes to the log files.
+	WriteErrors uint64 `json:"write_errors"`
+}
+
+// Stats returns a snapshot of the backend counters.
+func (v *Backend) Stats() *Stats {
+	s := &Stats{
+		Levels:      make(map[string]LevelStats),
+		WriteErrors: v.stats.writeErrors.Load(),
+		Truncated:   v.stats.truncated.Load(),
+		Dropped:     v.stats.dropped.Load(),
+		Suppressed:  v.stats.suppressed.Load(),
+
+		LowDiskDropped: v.stats.lowDiskDropped.Load(),
+	}
+	for l, f := range v.fileMap {
+		s.Levels[levelName(l)] = LevelStats{
+			Records:     f.stats.records.Load(),
+			Bytes:       f.stats.bytes.Load(),
+			Rotations:   f.stats.rotations.Load(),
+			WriteErrors: f.stats.writeErrors.Load(),
+		}
+	}
+	return s
+}
+
+// countRecord increments the record counter for the log level.
+func (v *Backend) countRecord(level slog.Level) {
+	// File map is not modified after the backend is created, so it is safe to
+	// read without the lock.
+	if f, ok := v.fileMap[normalize(level)]; ok {
+		f.stats.records.Add(1)
+	}
+}
+
+// PublishExpvar publishes the backend counters as an expvar variable with the
+// input name, which are then served at /debug/vars with the expvar package's
+// handler. Like expvar.Publish, it panics if the name is already in use.
+func (v *Backend) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return v.Stats() }))
+}
+
+// MetricsHandler returns an http.Handler that reports the backend counters in
+// the Prometheus text exposition format. All metric names have the "sglog_"
+// prefix and per-level metrics have the "level" label.
+func MetricsHandler(backend *Backend) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write([]byte(backend.Stats().prometheus()))
+	})
+}
+
+// prometheus returns the counters in the Prometheus text exposition format.
+func (s *Stats) prometheus() string {
+	var sb strings.Builder
+
+	levels := slices.Sorted(maps.Keys(s.Levels))
+	perLevel := []struct {
+		name, help string
+		value      func(LevelStats) uint64
+	}{
+		{"sglog_records_total", "Number of log messages by level.", func(ls LevelStats) uint64 { return ls.Records }},
+		{"sglog_file_bytes_total", "Number of bytes written to the log files by level.", func(ls LevelStats) uint64 { return ls.Bytes }},
+		{"sglog_file_rotations_total", "Number of log file rotations by level.", func(ls LevelStats) uint64 { return ls.Rotations }},
+		{"sglog_file_write_errors_total", "Number of failed log file writes by level.", func(ls LevelStats) uint64 { return ls.WriteErrors }},
+	}
+	for _, m := range perLevel {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s counter\n", m.name, m.help, m.name)
+		for _, name := range levels {
+			fmt.Fprintf(&sb, "%s{level=%q} %d\n", m.name, name, m.value(s.Levels[name]))
+		}
+	}
+
+	counters := []struct {
+		name, help string
+		value      uint64
+	}{
+		{"sglog_write_errors_total", "Number of log messages that could not be written.", s.WriteErrors},
+		{"sglog_truncated_total", "Number of log messages truncated to the maximum length.", s.Truncated},
+		{"sglog_dropped_total", "Number of log messages dropped by the full write queue.", s.Dropped},
+		{"sglog_suppressed_total", "Number of log messages dropped by sampling.", s.Suppressed},
+		{"sglog_low_disk_dropped_total", "Number of log messages dropped because of low free disk space.", s.LowDiskDropped},
+	}
+	for _, m := range counters {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", m.name, m.help, m.name, m.name, m.value)
+	}
+	return sb.String()
+}
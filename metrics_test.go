@@ -0,0 +1,67 @@
+package sglog
+
+import (
+	"expvar"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	backend := NewBackend(&Options{
+		Name:             "stats",
+		LogDirs:          []string{t.TempDir()},
+		LogMessageMaxLen: 100,
+		Sampling:         SampleOnce(),
+	})
+	defer backend.Close()
+
+	logger := slog.New(backend.Handler())
+	for i := 0; i < 3; i++ {
+		logger.Info("info message")
+	}
+	logger.Warn("warning message " + strings.Repeat("x", 100))
+	logger.Error("error message")
+
+	stats := backend.Stats()
+	if n := stats.Levels["INFO"].Records; n != 1 {
+		t.Errorf("want 1 INFO record, got %d", n)
+	}
+	if n := stats.Levels["WARN"].Records; n != 1 {
+		t.Errorf("want 1 WARN record, got %d", n)
+	}
+	if stats.Suppressed != 2 {
+		t.Errorf("want 2 suppressed records, got %d", stats.Suppressed)
+	}
+	if stats.Truncated != 1 {
+		t.Errorf("want 1 truncated record, got %d", stats.Truncated)
+	}
+	if stats.Levels["INFO"].Bytes <= stats.Levels["ERROR"].Bytes {
+		t.Errorf("want more INFO bytes than ERROR bytes, got %+v", stats.Levels)
+	}
+
+	// Expvar names cannot be reused, so repeated test runs need unique names.
+	name := "sglog_test_stats_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	backend.PublishExpvar(name)
+	if v := expvar.Get(name); v == nil || !strings.Contains(v.String(), `"suppressed":2`) {
+		t.Errorf("unexpected expvar value %v", v)
+	}
+
+	w := httptest.NewRecorder()
+	MetricsHandler(backend).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(w.Result().Body)
+	for _, want := range []string{
+		"# TYPE sglog_records_total counter\n",
+		`sglog_records_total{level="INFO"} 1` + "\n",
+		"sglog_suppressed_total 2\n",
+		"sglog_truncated_total 1\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("want %q in metrics output %q", want, body)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package sglog
 
 import (
+	"log/slog"
 	"os"
 	"time"
 )
@@ -24,6 +25,23 @@ type Options struct {
 	// LogFileMode is the log file mode/permissions.
 	LogFileMode os.FileMode
 
+	// LogFileNameTemplate is the template for the log file names, which can
+	// have the following fields. Default is DefaultFileNameTemplate, like
+	// "prog.host.user.log.INFO.20060102-150405.1234". Template must have the
+	// {level} and {time} fields and must not have path separators. Log files
+	// with other names are not reused or removed by the retention limits.
+	// Without the {seq} field, log files rotated within the same second get
+	// the following seconds as their {time}, so that their names are unique.
+	//
+	//	{name}   program name from the Name option
+	//	{host}   short host name
+	//	{user}   user name
+	//	{level}  log level name, like INFO
+	//	{time}   log file creation time, like 20060102-150405
+	//	{pid}    process id
+	//	{seq}    number of log files opened by the process for the level
+	LogFileNameTemplate string
+
 	// LogFileHeader when true writes the file header at the start of each log
 	// file.
 	LogFileHeader bool
@@ -32,10 +50,153 @@ type Options struct {
 	// log file as long as it doesn't cross the maximum log file size.
 	LogFileReuseDuration time.Duration
 
+	// LogFileRotateInterval if non-zero rotates the log files at wall-clock
+	// aligned interval boundaries irrespective of their size. For example, one
+	// hour rotates the log files at the start of every hour and 24 hours rotates
+	// the log files at midnight. Intervals shorter than a day are aligned to the
+	// midnight and intervals in multiples of a day are aligned to the Unix epoch
+	// date. Negative intervals are ignored.
+	LogFileRotateInterval time.Duration
+
+	// LogFileRotateUTC when true aligns the rotation intervals in UTC instead of
+	// the local time.
+	LogFileRotateUTC bool
+
+	// LogFileCompress when true compresses the log files with gzip in the
+	// background after they are rotated. Compressed log files keep their names
+	// with an additional ".gz" suffix and are never reused.
+	LogFileCompress bool
+
+	// LogFileMaxAge if non-zero removes the log files older than this duration.
+	LogFileMaxAge time.Duration
+
+	// LogFileMaxCount if non-zero limits the number of log files kept for each
+	// log level in a log directory. Oldest log files are removed first.
+	LogFileMaxCount int
+
+	// LogDirMaxSize if non-zero limits the total size of log files in bytes in
+	// each log directory. Oldest log files are removed first.
+	LogDirMaxSize uint64
+
+	// LogDirMinFreeSpace if non-zero is the minimum free disk space in bytes
+	// for the log directories. Free space is checked periodically and before
+	// log file rotations (where supported). Log files are moved to the next
+	// log directory with enough free space, and back when space is freed. When
+	// all log directories are low on space, the backend enters a degraded mode
+	// that drops DEBUG and INFO log messages and skips the DEBUG and INFO log
+	// files for other log messages, till space is freed.
+	LogDirMinFreeSpace uint64
+
+	// Formatter formats the log records into log lines. Default is the glog
+	// style TextFormatter. LogFileHeader should be disabled when formatters
+	// with structured output, like JSONFormatter, are used.
+	Formatter Formatter
+
 	// LogMessageMaxLen is the limit on length of a formatted log message,
 	// including the standard line prefix and trailing newline. Messages longer
-	// than this value are truncated.
+	// than this value are truncated by shortening the message and attribute
+	// values, marked with "...", so that the structured formats remain valid.
 	LogMessageMaxLen int
+
+	// LogToStderr when true writes the log messages to the standard error
+	// instead of the log files.
+	LogToStderr bool
+
+	// AlsoLogToStderr when true writes the log messages to the standard error
+	// in addition to the log files.
+	AlsoLogToStderr bool
+
+	// StderrThreshold if non-nil also writes the log messages at or above this
+	// level to the standard error in addition to the log files. Glog uses
+	// slog.LevelError as the threshold by default.
+	StderrThreshold slog.Leveler
+
+	// ContextExtractors extract attributes from the contexts of log messages,
+	// like request and trace ids. Default is the TraceParentExtractor; an
+	// empty, non-nil list disables the extraction.
+	ContextExtractors []ContextExtractor
+
+	// RedactRules redact the attribute values, like tokens and email
+	// addresses, before the log messages are formatted. Rules apply to the
+	// record attributes, the attributes added with WithAttrs and the context
+	// attributes alike.
+	RedactRules []RedactRule
+
+	// Sampling if non-nil limits the number of log messages written from each
+	// log statement. VModules can override it with SetVModuleSampling.
+	Sampling *Sampling
+
+	// LogDedupTimeout if non-zero collapses consecutive identical log messages
+	// (same source location, message and attributes) in each log file into a
+	// single summary line with the repeat count and the first and last repeat
+	// times. Summary lines are written when a different log message arrives,
+	// after this timeout, when the log file is rotated or when the backend is
+	// flushed or closed.
+	LogDedupTimeout time.Duration
+
+	// ErrorHandler if non-nil is called with the errors from the log file
+	// operations, like failures to open, write or rotate the log files, instead
+	// of printing them to the standard error. It may be called with the
+	// backend's lock held, so it must not log messages to the same backend.
+	ErrorHandler func(err *LogError)
+
+	// RecentLogSize if non-zero keeps the last this many log lines for each
+	// log level in memory, which are available through Backend.Recent and
+	// RecentLogsHandler.
+	RecentLogSize int
+
+	// LogQueueSize if non-zero enables asynchronous writes. Log messages are
+	// queued for a background goroutine that writes them to the log files. The
+	// queue can hold up to this many log messages.
+	LogQueueSize int
+
+	// LogQueuePolicy determines what happens to a log message when the
+	// asynchronous write queue is full.
+	LogQueuePolicy QueuePolicy
+}
+
+// QueuePolicy determines the behavior of the asynchronous write queue when it
+// is full.
+type QueuePolicy int
+
+const (
+	// QueueBlock blocks the logging goroutine till the queue has space.
+	QueueBlock QueuePolicy = iota
+
+	// QueueDropNewest drops the incoming log message.
+	QueueDropNewest
+
+	// QueueDropOldest drops the oldest queued log message to make space for the
+	// incoming log message.
+	QueueDropOldest
+)
+
+// rotationWindow returns the start and end times of the wall-clock aligned
+// rotation interval that includes the input time.
+func (v *Options) rotationWindow(t time.Time) (start, end time.Time) {
+	const day = 24 * time.Hour
+
+	loc := time.Local
+	if v.LogFileRotateUTC {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+	if v.LogFileRotateInterval%day == 0 {
+		ndays := int(v.LogFileRotateInterval / day)
+		epochDays := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / int64(day/time.Second))
+		start = midnight.AddDate(0, 0, -(epochDays % ndays))
+		return start, start.AddDate(0, 0, ndays)
+	}
+
+	start = midnight.Add(t.Sub(midnight).Truncate(v.LogFileRotateInterval))
+	end = start.Add(v.LogFileRotateInterval)
+	// Intervals that do not divide a day evenly restart at the next midnight.
+	if next := midnight.AddDate(0, 0, 1); end.After(next) {
+		end = next
+	}
+	return start, end
 }
 
 func (v *Options) setDefaults() {
@@ -56,6 +217,18 @@ func (v *Options) setDefaults() {
 	if v.LogFileReuseDuration == 0 {
 		v.LogFileReuseDuration = 16 * time.Hour
 	}
+	if v.LogFileNameTemplate == "" {
+		v.LogFileNameTemplate = DefaultFileNameTemplate
+	}
+	if v.LogFileRotateInterval < 0 {
+		v.LogFileRotateInterval = 0
+	}
+	if v.Formatter == nil {
+		v.Formatter = TextFormatter{}
+	}
+	if v.ContextExtractors == nil {
+		v.ContextExtractors = []ContextExtractor{TraceParentExtractor{}}
+	}
 	if v.LogMessageMaxLen == 0 {
 		v.LogMessageMaxLen = 15000
 	}
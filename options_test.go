@@ -0,0 +1,75 @@
+package sglog
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRotationWindow(t *testing.T) {
+	now := time.Date(2024, 3, 7, 13, 45, 10, 0, time.UTC)
+
+	testcases := []struct {
+		interval   time.Duration
+		start, end time.Time
+	}{
+		{time.Hour, time.Date(2024, 3, 7, 13, 0, 0, 0, time.UTC), time.Date(2024, 3, 7, 14, 0, 0, 0, time.UTC)},
+		{15 * time.Minute, time.Date(2024, 3, 7, 13, 45, 0, 0, time.UTC), time.Date(2024, 3, 7, 14, 0, 0, 0, time.UTC)},
+		{5 * time.Hour, time.Date(2024, 3, 7, 10, 0, 0, 0, time.UTC), time.Date(2024, 3, 7, 15, 0, 0, 0, time.UTC)},
+		{7 * time.Hour, time.Date(2024, 3, 7, 7, 0, 0, 0, time.UTC), time.Date(2024, 3, 7, 14, 0, 0, 0, time.UTC)},
+		{22 * time.Hour, time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 7, 22, 0, 0, 0, time.UTC)},
+		{24 * time.Hour, time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{48 * time.Hour, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testcases {
+		opts := &Options{LogFileRotateInterval: tc.interval, LogFileRotateUTC: true}
+		start, end := opts.rotationWindow(now)
+		if !start.Equal(tc.start) || !end.Equal(tc.end) {
+			t.Errorf("interval %v: got [%v, %v), want [%v, %v)", tc.interval, start, end, tc.start, tc.end)
+		}
+	}
+}
+
+func TestRotateInterval(t *testing.T) {
+	for _, interval := range []time.Duration{time.Hour, -time.Hour} {
+		backend := NewBackend(&Options{
+			Name:                  "interval",
+			LogDirs:               []string{t.TempDir()},
+			LogFileRotateInterval: interval,
+		})
+		logger := slog.New(backend.Handler())
+		logger.Info("first message")
+		logger.Info("second message")
+
+		f := backend.fileMap[slog.LevelInfo]
+		if interval < 0 {
+			// Negative intervals never rotate the log files.
+			if n := f.stats.rotations.Load(); n != 0 || !f.rotateAt.IsZero() {
+				t.Errorf("interval %v: want no rotations, got %d until %v", interval, n, f.rotateAt)
+			}
+			backend.Close()
+			continue
+		}
+
+		// Log files are rotated by the first write after the interval boundary.
+		if n := f.stats.rotations.Load(); n != 0 {
+			t.Errorf("interval %v: want no rotations before the boundary, got %d", interval, n)
+		}
+		path := backend.LogFiles()[slog.LevelInfo]
+		backend.mu.Lock()
+		f.rotateAt = time.Now().Add(-time.Second)
+		backend.mu.Unlock()
+		logger.Info("third message")
+		if n := f.stats.rotations.Load(); n != 1 {
+			t.Errorf("interval %v: want one rotation at the boundary, got %d", interval, n)
+		}
+		if newPath := backend.LogFiles()[slog.LevelInfo]; newPath == path {
+			t.Errorf("interval %v: want a new log file after the boundary", interval)
+		}
+		if !f.rotateAt.After(time.Now()) {
+			t.Errorf("interval %v: want next rotation in the future, got %v", interval, f.rotateAt)
+		}
+		backend.Close()
+	}
+}
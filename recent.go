@@ -0,0 +1,181 @@
+package sglog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentEntry is a log line in the recent log ring buffers.
+type recentEntry struct {
+	time    time.Time
+	level   slog.Level
+	vmodule string
+	line    string
+}
+
+// recentRing holds the last log lines for a log level.
+type recentRing struct {
+	entries []*recentEntry
+	next    int
+	full    bool
+}
+
+func (r *recentRing) add(e *recentEntry) {
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	r.full = r.full || r.next == 0
+}
+
+// list returns the log lines in the order they were logged.
+func (r *recentRing) list() []*recentEntry {
+	if !r.full {
+		return append([]*recentEntry(nil), r.entries[:r.next]...)
+	}
+	return append(append([]*recentEntry(nil), r.entries[r.next:]...), r.entries[:r.next]...)
+}
+
+// recentLogs holds the recent log ring buffers for all log levels.
+type recentLogs struct {
+	mu    sync.Mutex
+	rings map[slog.Level]*recentRing
+}
+
+func newRecentLogs(size int) *recentLogs {
+	rl := &recentLogs{rings: make(map[slog.Level]*recentRing)}
+	for _, l := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, LevelFatal} {
+		rl.rings[l] = &recentRing{entries: make([]*recentEntry, size)}
+	}
+	return rl
+}
+
+// addRecent adds the log entry to the ring buffers of all levels in its
+// [minLevel, maxLevel] range, like the log files.
+func (v *Backend) addRecent(e *logEntry) {
+	if v.recent == nil {
+		return
+	}
+	re := &recentEntry{
+		time:    e.time,
+		level:   e.maxLevel,
+		vmodule: e.vmodule,
+		line:    string(bytes.TrimSuffix(e.msg, []byte("\n"))),
+	}
+
+	v.recent.mu.Lock()
+	defer v.recent.mu.Unlock()
+
+	for l, r := range v.recent.rings {
+		if l >= e.minLevel && l <= e.maxLevel {
+			r.add(re)
+		}
+	}
+}
+
+// recentEntries returns the recent log lines for the log level that are
+// selected by the filter, in the order they were logged. At most n lines are
+// returned if n is positive.
+func (v *Backend) recentEntries(level slog.Level, n int, filter func(*recentEntry) bool) []*recentEntry {
+	if v.recent == nil {
+		return nil
+	}
+
+	v.recent.mu.Lock()
+	entries := v.recent.rings[normalize(level)].list()
+	v.recent.mu.Unlock()
+
+	if filter != nil {
+		selected := entries[:0]
+		for _, e := range entries {
+			if filter(e) {
+				selected = append(selected, e)
+			}
+		}
+		entries = selected
+	}
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries
+}
+
+// Recent returns the last n formatted log lines, without the trailing
+// newlines, from the recent log ring buffer of the level. Like the log files,
+// a level's ring buffer also holds the log lines from the higher levels. Lines
+// are in the order they were logged. Returns nil if Options.RecentLogSize is
+// zero.
+func (v *Backend) Recent(level slog.Level, n int) []string {
+	var lines []string
+	for _, e := range v.recentEntries(level, n, nil) {
+		lines = append(lines, e.line)
+	}
+	return lines
+}
+
+// RecentLogsHandler returns an http.Handler that serves the recent log lines
+// from the backend's ring buffers, typically at /debug/logs. Ring buffers are
+// enabled with Options.RecentLogSize.
+//
+// Requests can use the following query parameters. Response is plain text,
+// with one log line per line, or JSON with the format=json query parameter.
+//
+//	level    log level of the ring buffer, as accepted by ParseLevel (default INFO)
+//	n        maximum number of log lines (default 100; zero for all)
+//	q        only the log lines that contain this substring
+//	vmodule  only the log lines with this vmodule
+func RecentLogsHandler(backend *Backend) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		level := slog.LevelInfo
+		if s := r.FormValue("level"); s != "" {
+			l, err := ParseLevel(s)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid level: %v", err), http.StatusBadRequest)
+				return
+			}
+			level = l
+		}
+		n := 100
+		if s := r.FormValue("n"); s != "" {
+			v, err := strconv.Atoi(s)
+			if err != nil || v < 0 {
+				http.Error(w, fmt.Sprintf("invalid n %q", s), http.StatusBadRequest)
+				return
+			}
+			n = v
+		}
+		q, vmodule, hasVModule := r.FormValue("q"), r.FormValue("vmodule"), r.Form.Has("vmodule")
+
+		entries := backend.recentEntries(level, n, func(e *recentEntry) bool {
+			return strings.Contains(e.line, q) && (!hasVModule || e.vmodule == vmodule)
+		})
+
+		if r.FormValue("format") == "json" {
+			type jsonEntry struct {
+				Time    time.Time `json:"time"`
+				Level   string    `json:"level"`
+				VModule string    `json:"vmodule,omitempty"`
+				Line    string    `json:"line"`
+			}
+			out := make([]jsonEntry, 0, len(entries))
+			for _, e := range entries {
+				out = append(out, jsonEntry{Time: e.time, Level: levelName(e.level), VModule: e.vmodule, Line: e.line})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(out)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, e := range entries {
+			fmt.Fprintln(w, e.line)
+		}
+	})
+}
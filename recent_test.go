@@ -0,0 +1,103 @@
+package sglog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecent(t *testing.T) {
+	backend := NewBackend(&Options{
+		Name:          "recent",
+		LogDirs:       []string{t.TempDir()},
+		RecentLogSize: 5,
+	})
+	defer backend.Close()
+
+	network := VModule("recent-network", slog.LevelInfo)
+	logger := slog.New(backend.Handler())
+	for i := 0; i < 10; i++ {
+		logger.Info(fmt.Sprintf("info message %d", i))
+	}
+	logger.With(network).Error("network error")
+	logger.Warn("warning message")
+
+	lines := backend.Recent(slog.LevelInfo, 3)
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "] info message 9") || !strings.HasSuffix(lines[2], "] warning message") {
+		t.Fatalf("unexpected recent INFO lines %q", lines)
+	}
+	if lines := backend.Recent(slog.LevelInfo, 0); len(lines) != 5 {
+		t.Fatalf("want 5 recent INFO lines, got %q", lines)
+	}
+	if lines := backend.Recent(slog.LevelError, 10); len(lines) != 1 || !strings.Contains(lines[0], "network error") {
+		t.Fatalf("unexpected recent ERROR lines %q", lines)
+	}
+
+	testcases := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"info message 7", "info message 8", "info message 9", "network error", "warning message"}},
+		{"?level=warn", []string{"network error", "warning message"}},
+		{"?n=1&q=info", []string{"info message 9"}},
+		{"?vmodule=recent-network", []string{"network error"}},
+	}
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		RecentLogsHandler(backend).ServeHTTP(w, httptest.NewRequest("GET", "/debug/logs"+tc.query, nil))
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != len(tc.want) {
+			t.Errorf("%d: want %d lines, got %q", i, len(tc.want), lines)
+			continue
+		}
+		for j := range lines {
+			if !strings.Contains(lines[j], tc.want[j]) {
+				t.Errorf("%d: want %q in line %q", i, tc.want[j], lines[j])
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	RecentLogsHandler(backend).ServeHTTP(w, httptest.NewRequest("GET", "/debug/logs?format=json&level=error", nil))
+	var entries []struct {
+		Level   string `json:"level"`
+		VModule string `json:"vmodule"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Level != "ERROR" || entries[0].VModule != "recent-network" {
+		t.Fatalf("unexpected JSON entries %+v", entries)
+	}
+
+	w = httptest.NewRecorder()
+	RecentLogsHandler(backend).ServeHTTP(w, httptest.NewRequest("GET", "/debug/logs?level=bad", nil))
+	if w.Code != 400 {
+		t.Fatalf("want status 400 for invalid level, got %d", w.Code)
+	}
+}
+
+func TestRecentRedactedVModule(t *testing.T) {
+	backend := NewBackend(&Options{
+		Name:          "recent",
+		LogDirs:       []string{t.TempDir()},
+		RecentLogSize: 5,
+		RedactRules:   []RedactRule{{Key: "token"}},
+	})
+	defer backend.Close()
+
+	network := VModule("recent-redact-network", slog.LevelInfo)
+	logger := slog.New(backend.Handler())
+	logger.With(network).Info("redacted message", "token", "secret")
+	logger.With(network).Info("plain message")
+
+	w := httptest.NewRecorder()
+	RecentLogsHandler(backend).ServeHTTP(w, httptest.NewRequest("GET", "/debug/logs?vmodule=recent-redact-network", nil))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `redacted message vmodule="recent-redact-network" token="REDACTED"`) || !strings.Contains(lines[1], "plain message") {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+}
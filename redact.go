@@ -0,0 +1,186 @@
+package sglog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+)
+
+// defaultMask is the replacement for the redacted values when the rule has no
+// mask.
+const defaultMask = "REDACTED"
+
+// RedactRule describes the attribute values to redact from the log messages
+// and how to replace them.
+//
+// Key matches the attribute keys and Path matches the dotted attribute paths,
+// like "request.header.token" for the "token" attribute in the "header" group
+// of the "request" group, which is how the text formatter names the
+// attributes. Both are glob patterns as in path.Match. Whole attribute value,
+// including all attributes of a group, is replaced when the key or path
+// matches.
+//
+// Value matches the string attribute values (including the string form of
+// slog.Any values) and only the matched parts of the values are replaced, so it
+// can redact email addresses embedded in longer strings, for example.
+//
+// A rule with more than one of Key, Path and Value requires all of them to
+// match. Redacted values are replaced with Mask, or "REDACTED" if Mask is
+// empty. When Hash is true, values are replaced with a "sha256:" prefixed hex
+// encoded HMAC-SHA256 of the value keyed with HashKey instead, so that the
+// same values can still be correlated across log messages without revealing
+// them. HashKey must be a secret, because unkeyed hashes of short values, like
+// phone numbers, can be reversed by brute force. Hash rules without a HashKey
+// are refused by NewBackend, which reports them on the standard error and
+// masks their values instead.
+type RedactRule struct {
+	Key   string
+	Path  string
+	Value *regexp.Regexp
+
+	Mask string
+
+	Hash    bool
+	HashKey []byte
+}
+
+// check returns an error if the rule is not valid.
+func (rule *RedactRule) check() error {
+	if rule.Hash && len(rule.HashKey) == 0 {
+		return fmt.Errorf("redact rule with key %q, path %q and value %v has Hash without a HashKey", rule.Key, rule.Path, rule.Value)
+	}
+	return nil
+}
+
+// checkRedactRules checks the redaction rules in the options. Invalid hash
+// rules are reported on the standard error and their values are masked
+// instead, so that they still redact the values.
+func (v *Backend) checkRedactRules() {
+	var rules []RedactRule
+	for i := range v.opts.RedactRules {
+		if err := v.opts.RedactRules[i].check(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid redact rule (values are masked): %v\n", err)
+			if rules == nil {
+				// Rules are copied, so that the caller's rules are not modified.
+				rules = slices.Clone(v.opts.RedactRules)
+			}
+			rules[i].Hash = false
+		}
+	}
+	if rules != nil {
+		v.opts.RedactRules = rules
+	}
+}
+
+// replace returns the replacement for a redacted value.
+func (rule *RedactRule) replace(s string) string {
+	if rule.Hash {
+		m := hmac.New(sha256.New, rule.HashKey)
+		m.Write([]byte(s))
+		return "sha256:" + hex.EncodeToString(m.Sum(nil)[:16])
+	}
+	if rule.Mask != "" {
+		return rule.Mask
+	}
+	return defaultMask
+}
+
+// matchName returns true if the rule's key and path patterns match the
+// attribute. Rules without key and path patterns match all attributes.
+func (rule *RedactRule) matchName(key, apath string) bool {
+	if rule.Key != "" {
+		if ok, _ := path.Match(rule.Key, key); !ok {
+			return false
+		}
+	}
+	if rule.Path != "" {
+		if ok, _ := path.Match(rule.Path, apath); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// redact returns a copy of the log record with the attribute values redacted
+// as per the redaction rules. Input record is returned if nothing is redacted.
+func (v *Backend) redact(r slog.Record) slog.Record {
+	if len(v.opts.RedactRules) == 0 || r.NumAttrs() == 0 {
+		return r
+	}
+
+	redacted := false
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		na, ok := v.redactAttr(a, "")
+		attrs = append(attrs, na)
+		redacted = redacted || ok
+		return true
+	})
+	if !redacted {
+		return r
+	}
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(attrs...)
+	return nr
+}
+
+// redactAttr returns the attribute with its value redacted as per the
+// redaction rules. Prefix holds the dotted path of the enclosing groups.
+// Returns true if the attribute is redacted. Attributes that are not redacted
+// are returned unchanged, without resolving their values, so that the special
+// attributes like vmodules are still recognized later.
+func (v *Backend) redactAttr(a slog.Attr, prefix string) (slog.Attr, bool) {
+	value := a.Value.Resolve()
+	apath := prefix + a.Key
+
+	for i := range v.opts.RedactRules {
+		rule := &v.opts.RedactRules[i]
+		if rule.Value == nil && (rule.Key != "" || rule.Path != "") && rule.matchName(a.Key, apath) {
+			return slog.String(a.Key, rule.replace(value.String())), true
+		}
+	}
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		gprefix := prefix
+		if a.Key != "" {
+			gprefix = apath + "."
+		}
+		redacted := false
+		gattrs := value.Group()
+		nattrs := make([]slog.Attr, len(gattrs))
+		for i, ga := range gattrs {
+			var ok bool
+			nattrs[i], ok = v.redactAttr(ga, gprefix)
+			redacted = redacted || ok
+		}
+		if !redacted {
+			return a, false
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(nattrs...)}, true
+
+	case slog.KindString, slog.KindAny:
+		s := value.String()
+		redacted := false
+		for i := range v.opts.RedactRules {
+			rule := &v.opts.RedactRules[i]
+			if rule.Value == nil || !rule.matchName(a.Key, apath) {
+				continue
+			}
+			if ns := rule.Value.ReplaceAllStringFunc(s, rule.replace); ns != s {
+				s, redacted = ns, true
+			}
+		}
+		if redacted {
+			return slog.String(a.Key, s), true
+		}
+	}
+	return a, false
+}
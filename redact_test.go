@@ -0,0 +1,84 @@
+package sglog
+
+import (
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactRules(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:    "redact",
+		LogDirs: []string{dir},
+		RedactRules: []RedactRule{
+			{Key: "password"},
+			{Key: "*token", Mask: "***"},
+			{Path: "req.header.cookie"},
+			{Key: "user", Hash: true, HashKey: []byte("secret")},
+			{Value: regexp.MustCompile(`[a-z]+@example\.com`), Mask: "<email>"},
+		},
+	})
+
+	logger := slog.New(backend.Handler()).With("password", "hunter2").WithGroup("req")
+	logger.Info("message",
+		"auth_token", "tokvalue",
+		"cookie", "top level",
+		slog.Group("header", "cookie", "c1", "accept", "text/plain"),
+		"user", "alice",
+		"note", "mail bob@example.com or carol@example.com",
+		"count", 10)
+	backend.Close()
+
+	lines := readLines(t, filepath.Join(dir, "redact.INFO"))
+	if len(lines) != 1 {
+		t.Fatalf("want 1 log line, got %d", len(lines))
+	}
+	line := lines[0]
+
+	for _, want := range []string{
+		`password="REDACTED"`,
+		`req.auth_token="***"`,
+		`req.cookie="top level"`,
+		`req.header.cookie="REDACTED"`,
+		`req.header.accept="text/plain"`,
+		`req.user="sha256:`,
+		`req.note="mail <email> or <email>"`,
+		`req.count=10`,
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("want %q in %q", want, line)
+		}
+	}
+	for _, secret := range []string{"hunter2", "tokvalue", "c1", "alice", "example.com"} {
+		if strings.Contains(line, secret) {
+			t.Errorf("want %q redacted in %q", secret, line)
+		}
+	}
+}
+
+func TestRedactHashWithoutKey(t *testing.T) {
+	dir := t.TempDir()
+	rules := []RedactRule{{Key: "user", Hash: true}}
+	backend := NewBackend(&Options{
+		Name:        "redact",
+		LogDirs:     []string{dir},
+		RedactRules: rules,
+	})
+	slog.New(backend.Handler()).Info("message", "user", "alice")
+	backend.Close()
+
+	// Hash rules without a key mask the values instead of hashing them.
+	lines := readLines(t, filepath.Join(dir, "redact.INFO"))
+	if len(lines) != 1 || !strings.Contains(lines[0], `user="REDACTED"`) {
+		t.Fatalf("want masked user value, got %q", lines)
+	}
+	if !rules[0].Hash {
+		t.Errorf("caller's redact rules must not be modified")
+	}
+	if err := rules[0].check(); err == nil {
+		t.Errorf("hash rule without a key must be invalid")
+	}
+}
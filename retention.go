@@ -0,0 +1,144 @@
+package sglog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// oldFile describes a log file created by a program with the same name, host
+// and user as the backend. Compressed log files are also included.
+type oldFile struct {
+	path  string
+	level *levelFile
+	time  time.Time
+	size  int64
+
+	// active is true for the log files currently open by the backend. Active
+	// log files are never removed, but are accounted in the limits.
+	active bool
+}
+
+// removeOldFiles removes the log files that cross the configured retention
+// limits in all log directories. Only the log files matching the backend's
+// log file names are considered, so files created by other programs are never
+// touched. Caller must hold the backend lock.
+func (v *Backend) removeOldFiles(now time.Time) {
+	if v.opts.LogFileMaxAge == 0 && v.opts.LogFileMaxCount == 0 && v.opts.LogDirMaxSize == 0 {
+		return
+	}
+
+	var seen []string
+	for _, dir := range v.opts.LogDirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if slices.Contains(seen, dir) {
+			continue
+		}
+		seen = append(seen, dir)
+
+		if err := v.removeOldFilesInDir(dir, now); err != nil {
+			v.reportError(ErrorRemove, nil, dir, err)
+		}
+	}
+}
+
+func (v *Backend) removeOldFilesInDir(dir string, now time.Time) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var files []*oldFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		for _, f := range v.fileMap {
+			t, err := f.fileTime(strings.TrimSuffix(entry.Name(), compressSuffix))
+			if err != nil {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				break
+			}
+			fpath := filepath.Join(dir, entry.Name())
+			files = append(files, &oldFile{
+				path:   fpath,
+				level:  f,
+				time:   t,
+				size:   info.Size(),
+				active: f.file != nil && sameFile(f.file.Name(), fpath),
+			})
+			break
+		}
+	}
+
+	// Newest files first.
+	slices.SortFunc(files, func(a, b *oldFile) int {
+		return b.time.Compare(a.time)
+	})
+
+	remove := func(file *oldFile) bool {
+		// Log files that are in use by other processes are not removed.
+		if file.active || isLocked(file.path) {
+			return false
+		}
+		if err := os.Remove(file.path); err != nil {
+			v.reportError(ErrorRemove, file.level, file.path, err)
+			return false
+		}
+		return true
+	}
+
+	var kept []*oldFile
+	counts := make(map[*levelFile]int)
+	for _, file := range files {
+		if v.opts.LogFileMaxAge > 0 && now.Sub(file.time) > v.opts.LogFileMaxAge {
+			if remove(file) {
+				continue
+			}
+		}
+		if v.opts.LogFileMaxCount > 0 && counts[file.level] >= v.opts.LogFileMaxCount {
+			if remove(file) {
+				continue
+			}
+		}
+		counts[file.level]++
+		kept = append(kept, file)
+	}
+
+	if v.opts.LogDirMaxSize > 0 {
+		var total uint64
+		for _, file := range kept {
+			total += uint64(file.size)
+		}
+		for i := len(kept) - 1; i >= 0 && total > v.opts.LogDirMaxSize; i-- {
+			if remove(kept[i]) {
+				total -= uint64(kept[i].size)
+			}
+		}
+	}
+	return nil
+}
+
+// sameFile returns true if both paths refer to the same file name in the same
+// directory.
+func sameFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+	aa, err := filepath.Abs(a)
+	if err != nil {
+		return false
+	}
+	ba, err := filepath.Abs(b)
+	if err != nil {
+		return false
+	}
+	return aa == ba
+}
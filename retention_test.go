@@ -0,0 +1,62 @@
+package sglog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemoveOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	opts := &Options{
+		Name:            "retention",
+		LogDirs:         []string{dir},
+		LogFileMaxAge:   48 * time.Hour,
+		LogFileMaxCount: 3,
+	}
+
+	// Create fake log files using the backend's naming scheme.
+	f := newTestLevelFile(opts, slog.LevelInfo)
+	now := time.Now()
+	var names []string
+	for i := 0; i < 5; i++ {
+		name := f.fileName(now.Add(-time.Duration(i) * time.Hour))
+		names = append(names, name)
+	}
+	names = append(names, f.fileName(now.Add(-72*time.Hour)))
+	foreign := []string{"other.INFO", "retention-other.log", "retention"}
+	for _, name := range append(names, foreign...) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backend := NewBackend(opts)
+	defer backend.Close()
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+	for i, name := range names {
+		if want := i < 3; exists(name) != want {
+			t.Errorf("file %q exists=%t, want %t", name, !want, want)
+		}
+	}
+	for _, name := range foreign {
+		if !exists(name) {
+			t.Errorf("foreign file %q must not be removed", name)
+		}
+	}
+}
+
+// newTestLevelFile returns a level file with the input options, for creating
+// log file names without a backend.
+func newTestLevelFile(opts *Options, level slog.Level) *levelFile {
+	opts.setDefaults()
+	v := &Backend{opts: opts}
+	v.setFileNameTemplate()
+	return v.newLevelFile(level)
+}
@@ -0,0 +1,111 @@
+package sglog
+
+import (
+	"sync"
+	"time"
+)
+
+// suppressedKey is the attribute key for the number of log messages dropped
+// by sampling since the previous log message from the same log statement.
+const suppressedKey = "suppressed"
+
+// Sampling limits the number of log messages written from each log statement,
+// identified by its program counter.
+//
+// In every Interval, the First log messages from a log statement are written
+// and thereafter only every Thereafter-th log message is written. Zero
+// Interval never resets the counts and zero Thereafter drops all log messages
+// after the first ones. Number of dropped log messages is reported with the
+// "suppressed" attribute in the next log message written from the same log
+// statement. FATAL log messages are never dropped.
+type Sampling struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+}
+
+// SampleEvery returns a sampling policy that writes at most one log message
+// from each log statement in every interval.
+func SampleEvery(interval time.Duration) *Sampling {
+	return &Sampling{Interval: interval, First: 1}
+}
+
+// SampleOnce returns a sampling policy that writes only the first log message
+// from each log statement.
+func SampleOnce() *Sampling {
+	return &Sampling{First: 1}
+}
+
+// samplingKey identifies the sampling state for a log statement. Same log
+// statement can have different sampling policies through vmodules, so the
+// state is kept separately for each vmodule, or nil for the backend's
+// sampling policy. Policies themselves are not part of the key, so that
+// changing the policies doesn't leave stale sampling state behind.
+type samplingKey struct {
+	pc      uintptr
+	vmodule *vmoduleValue
+}
+
+// samplingSite holds the sampling state for a log statement.
+type samplingSite struct {
+	mu sync.Mutex
+
+	// policy is the sampling policy the counts are for. Counts are reset when
+	// the policy is changed.
+	policy *Sampling
+
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// sample returns true if a log message from the log statement must be
+// written, along with the number of log messages dropped since the last
+// written log message. Vmodule is the source of the sampling policy, or nil
+// for the backend's sampling policy.
+func (v *Backend) sample(pc uintptr, vmodule *vmoduleValue, policy *Sampling, now time.Time) (bool, int) {
+	key := samplingKey{pc: pc, vmodule: vmodule}
+	x, ok := v.samplingSites.Load(key)
+	if !ok {
+		x, _ = v.samplingSites.LoadOrStore(key, new(samplingSite))
+	}
+	site := x.(*samplingSite)
+
+	site.mu.Lock()
+	defer site.mu.Unlock()
+
+	if site.policy != policy {
+		site.policy, site.start, site.count = policy, time.Time{}, 0
+	}
+	if policy.Interval > 0 && now.Sub(site.start) >= policy.Interval {
+		site.start, site.count = now, 0
+	}
+	site.count++
+	if site.count <= policy.First || (policy.Thereafter > 0 && (site.count-policy.First)%policy.Thereafter == 0) {
+		suppressed := site.suppressed
+		site.suppressed = 0
+		return true, suppressed
+	}
+	site.suppressed++
+	return false, 0
+}
+
+// sampling returns the sampling policy for the handler, which is the policy
+// from the first vmodule attribute with a sampling policy, if any, or the
+// backend's sampling policy. Vmodule of the policy is also returned, which is
+// nil for the backend's sampling policy.
+func (h *slogHandler) sampling() (*Sampling, *vmoduleValue) {
+	for _, goa := range h.goas {
+		for _, attr := range goa.Attrs {
+			if attr.Key != vmoduleKey {
+				continue
+			}
+			if value, ok := attr.Value.Any().(*vmoduleValue); ok {
+				if s := value.sampling.Load(); s != nil {
+					return s, value
+				}
+			}
+		}
+	}
+	return h.backend.opts.Sampling, nil
+}
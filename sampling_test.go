@@ -0,0 +1,93 @@
+package sglog
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampling(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:     "sampling",
+		LogDirs:  []string{dir},
+		Sampling: &Sampling{First: 2, Thereafter: 3},
+	})
+	logger := slog.New(backend.Handler())
+
+	once := VModule("sampling-once", slog.LevelInfo)
+	SetVModuleSampling(once, SampleOnce())
+
+	for i := 1; i <= 10; i++ {
+		logger.Info("sampled message", "i", i)
+		logger.With(once).Info("once message", "i", i)
+	}
+	logger.Info("another statement")
+	backend.Close()
+
+	var sampled, onced []string
+	for _, line := range readLines(t, filepath.Join(dir, "sampling.INFO")) {
+		switch {
+		case strings.Contains(line, "sampled message"):
+			sampled = append(sampled, line[strings.Index(line, "]"):])
+		case strings.Contains(line, "once message"):
+			onced = append(onced, line[strings.Index(line, "]"):])
+		}
+	}
+
+	want := []string{
+		"] sampled message i=1",
+		"] sampled message i=2",
+		"] sampled message i=5 suppressed=2",
+		"] sampled message i=8 suppressed=2",
+	}
+	if strings.Join(sampled, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got sampled lines %q, want %q", sampled, want)
+	}
+	if len(onced) != 1 || !strings.HasSuffix(onced[0], "i=1") {
+		t.Errorf("got once lines %q", onced)
+	}
+}
+
+func TestSampleEvery(t *testing.T) {
+	backend := &Backend{}
+	policy := SampleEvery(time.Minute)
+
+	now := time.Now()
+	for i, want := range []bool{true, false, false} {
+		if ok, _ := backend.sample(1, nil, policy, now.Add(time.Duration(i)*time.Second)); ok != want {
+			t.Errorf("%d: got %t, want %t", i, ok, want)
+		}
+	}
+	if ok, suppressed := backend.sample(1, nil, policy, now.Add(time.Minute)); !ok || suppressed != 2 {
+		t.Errorf("got %t with %d suppressed, want true with 2 suppressed", ok, suppressed)
+	}
+}
+
+func TestSamplingPolicyChange(t *testing.T) {
+	backend := &Backend{}
+
+	// Sampling state is kept for each log statement, but not for each policy,
+	// so that the sampling state doesn't grow with the policy changes.
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		policy := SampleOnce()
+		if ok, _ := backend.sample(1, nil, policy, now); !ok {
+			t.Fatalf("%d: first log message with a new policy must be written", i)
+		}
+		if ok, _ := backend.sample(1, nil, policy, now); ok {
+			t.Fatalf("%d: second log message with the same policy must be dropped", i)
+		}
+	}
+
+	var sites int
+	backend.samplingSites.Range(func(any, any) bool {
+		sites++
+		return true
+	})
+	if sites != 1 {
+		t.Errorf("want one sampling site, got %d", sites)
+	}
+}
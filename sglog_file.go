@@ -26,7 +26,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 )
@@ -74,33 +76,90 @@ type levelFile struct {
 
 	level slog.Level
 
-	filePrefix string
+	// namePattern matches the log file names of the level.
+	namePattern *regexp.Regexp
 
 	file   *os.File
 	nbytes uint64
 
+	// dir is the log directory of the current log file and disk is the free
+	// disk space state when it was created.
+	dir  string
+	disk *diskState
+
+	// rotateAt is the time for the next wall-clock aligned log file rotation.
+	rotateAt time.Time
+
+	// nameTime is the time in the current log file name.
+	nameTime time.Time
+
+	stats fileStats
+
+	health healthState
+
+	// repeat holds the state of the last log message when repeated log
+	// messages are collapsed.
+	repeat *repeatState
+
 	fpaths []string
 }
 
 func (v *Backend) newLevelFile(level slog.Level) *levelFile {
-	return &levelFile{
-		backend:    v,
-		level:      level,
-		filePrefix: fmt.Sprintf("%s.%s.%s.log.%s", v.opts.Name, host, userName, level.String()),
+	f := &levelFile{
+		backend: v,
+		level:   level,
 	}
+	f.namePattern = f.fileNamePattern()
+	return f
+}
+
+// rotationDue returns true if the log file must be rotated before the next
+// write, because it is not open yet, it is full, the rotation interval has
+// ended or it must move to another log directory.
+func (f *levelFile) rotationDue(now time.Time) bool {
+	return f.file == nil || f.nbytes >= f.backend.opts.LogFileMaxSize || (!f.rotateAt.IsZero() && !now.Before(f.rotateAt)) || f.moveDir()
 }
 
 func (f *levelFile) Write(p []byte) (int, error) {
-	if f.file == nil || f.nbytes >= f.backend.opts.LogFileMaxSize {
-		if err := f.rotateFile(time.Now()); err != nil {
-			return 0, fmt.Errorf("could not create/rotate log file: %w", err)
+	now := time.Now()
+	if f.rotationDue(now) {
+		f.backend.checkDiskSpace()
+
+		// Summary of the repeated log messages belongs to the current log file.
+		f.flushRepeats()
+		f.repeat = nil
+
+		kind := ErrorRotate
+		if f.file == nil {
+			kind = ErrorOpen
 		}
+		if err := f.rotateFile(now); err != nil {
+			err = fmt.Errorf("could not create/rotate log file: %w", err)
+			f.backend.reportError(kind, f, "", err)
+			f.health.failing.Store(true)
+			return 0, err
+		}
+	}
+
+	n, err := f.write(p)
+	if err != nil {
+		f.backend.reportError(ErrorWrite, f, f.file.Name(), err)
+		f.health.failing.Store(true)
+		return n, err
+	}
+	if f.health.failing.Load() {
+		f.health.failing.Store(false)
 	}
+	return n, nil
+}
 
+// write writes to the current log file without rotating it.
+func (f *levelFile) write(p []byte) (int, error) {
 	for nwrote := 0; nwrote < len(p); {
 		n, err := f.file.Write(p)
 		nwrote += n
 		f.nbytes += uint64(n)
+		f.stats.bytes.Add(uint64(n))
 
 		if err != nil {
 			if errors.Is(err, io.ErrShortWrite) {
@@ -114,26 +173,33 @@ func (f *levelFile) Write(p []byte) (int, error) {
 }
 
 func (f *levelFile) Sync() error {
+	if f.file == nil {
+		return nil
+	}
 	return f.file.Sync()
 }
 
-func (f *levelFile) levelName() string {
-	return f.level.String()
+// Close syncs and closes the current log file, if any.
+func (f *levelFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	serr := f.file.Sync()
+	cerr := f.file.Close()
+	f.file = nil
+	return errors.Join(serr, cerr)
 }
 
-func (f *levelFile) fileName(t time.Time) string {
-	return f.filePrefix + t.Format(".20060102-150405.") + fmt.Sprintf("%d", pid)
+func (f *levelFile) levelName() string {
+	return levelName(f.level)
 }
 
 func (f *levelFile) fileTime(name string) (ts time.Time, err error) {
-	if !strings.HasPrefix(name, f.filePrefix) {
+	if strings.HasSuffix(name, compressSuffix) {
 		return ts, os.ErrInvalid
 	}
-	fs := strings.Split(name, ".")
-	if len(fs) != 7 {
-		return ts, os.ErrInvalid
-	}
-	return time.ParseInLocation("20060102-150405", fs[5], time.Local)
+	ts, _, err = f.parseFileName(name)
+	return ts, err
 }
 
 func (f *levelFile) linkName(t time.Time) string {
@@ -148,14 +214,18 @@ func (f *levelFile) lastFileName(dir string) (string, error) {
 
 	var lastName string
 	var maxTime time.Time
+	var maxSeq int
 	for _, entry := range entries {
-		t, err := f.fileTime(entry.Name())
+		if strings.HasSuffix(entry.Name(), compressSuffix) {
+			continue
+		}
+		t, seq, err := f.parseFileName(entry.Name())
 		if err != nil {
 			continue
 		}
-		if t.After(maxTime) {
+		if t.After(maxTime) || (t.Equal(maxTime) && seq > maxSeq) {
 			lastName = entry.Name()
-			maxTime = t
+			maxTime, maxSeq = t, seq
 		}
 	}
 	if lastName == "" {
@@ -165,80 +235,124 @@ func (f *levelFile) lastFileName(dir string) (string, error) {
 	return lastName, nil
 }
 
-func (f *levelFile) filePath(dir string, t time.Time) (string, int64, error) {
+// filePath returns the log file path in the directory. Returns true if the
+// path refers to an existing log file that can be reused.
+func (f *levelFile) filePath(dir string, t time.Time) (string, bool, error) {
 	if len(f.fpaths) == 0 {
 		lastName, err := f.lastFileName(dir)
 		if err != nil {
-			return "", 0, err
+			return "", false, err
 		}
 
 		if lastName != "" {
 			lastFileTime, err := f.fileTime(lastName)
 			if err != nil {
-				return "", 0, err
+				return "", false, err
 			}
 
-			if lastFileTime.After(t.Truncate(f.backend.opts.LogFileReuseDuration)) {
+			reusable := lastFileTime.After(t.Truncate(f.backend.opts.LogFileReuseDuration))
+			if f.backend.opts.LogFileRotateInterval > 0 {
+				start, _ := f.backend.opts.rotationWindow(t)
+				reusable = reusable && !lastFileTime.Before(start)
+			}
+			if reusable {
 				lastPath := filepath.Join(dir, lastName)
 				fstat, err := os.Stat(lastPath)
 				if err != nil {
-					return "", 0, err
+					return "", false, err
 				}
 
 				if size := fstat.Size(); uint64(size) < f.backend.opts.LogFileMaxSize {
-					return lastPath, size, nil
+					return lastPath, true, nil
 				}
 			}
 		}
 	}
 
 	fpath := filepath.Join(dir, f.fileName(t))
-	return fpath, 0, nil
+	return fpath, false, nil
+}
+
+// openFile opens the log file in append mode and locks it. Existing log files
+// locked by another live process are not reused, in which case a new log file
+// is opened instead. New log files are opened even if they are locked, which
+// is safe because all writes are appends.
+func (f *levelFile) openFile(dir string, t time.Time) (*os.File, string, error) {
+	fpath, reuse, err := f.filePath(dir, t)
+	if err != nil {
+		return nil, "", err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	fp, err := os.OpenFile(fpath, flags, f.backend.opts.LogFileMode)
+	if err != nil {
+		return nil, "", err
+	}
+	locked, err := lockFile(fp)
+	if err != nil {
+		f.backend.reportError(ErrorOpen, f, fpath, fmt.Errorf("could not lock log file: %w", err))
+	}
+	if reuse && !locked && err == nil {
+		if err := fp.Close(); err != nil {
+			f.backend.reportError(ErrorClose, f, fpath, err)
+		}
+		fpath = filepath.Join(dir, f.fileName(t))
+		if fp, err = os.OpenFile(fpath, flags, f.backend.opts.LogFileMode); err != nil {
+			return nil, "", err
+		}
+		if _, err := lockFile(fp); err != nil {
+			f.backend.reportError(ErrorOpen, f, fpath, fmt.Errorf("could not lock log file: %w", err))
+		}
+	}
+	return fp, fpath, nil
 }
 
 func (f *levelFile) createFile(t time.Time) (fp *os.File, filename string, err error) {
 	link := f.linkName(t)
 
 	var lastErr error
-	for _, dir := range f.backend.opts.LogDirs {
-		fpath, offset, err := f.filePath(dir, t)
+	for _, dir := range f.backend.logDirs() {
+		fp, fpath, err := f.openFile(dir, t)
 		if err != nil {
+			f.backend.reportError(ErrorOpen, f, dir, err)
 			lastErr = err
 			continue
 		}
-
-		flags := os.O_WRONLY | os.O_CREATE
-		fp, err := os.OpenFile(fpath, flags, f.backend.opts.LogFileMode)
+		fstat, err := fp.Stat()
 		if err != nil {
-			lastErr = err
-			continue
-		}
-		if _, err := fp.Seek(offset, io.SeekStart); err != nil {
+			f.backend.reportError(ErrorOpen, f, fpath, err)
 			lastErr = err
 			if err := fp.Close(); err != nil {
-				fmt.Fprintf(os.Stderr, "could not close file (ignored): %v\n", err)
+				f.backend.reportError(ErrorClose, f, fpath, err)
 			}
 			continue
 		}
-		f.nbytes = uint64(offset)
+		f.nbytes = uint64(fstat.Size())
+		f.dir = dir
 
 		{
 			fname := filepath.Base(fpath)
 			symlink := filepath.Join(dir, link)
 			if err := os.Remove(symlink); err != nil && !errors.Is(err, os.ErrNotExist) {
-				fmt.Fprintf(os.Stderr, "could not remove symlink %q (ignored): %v\n", symlink, err)
+				f.backend.reportError(ErrorSymlink, f, symlink, err)
 			}
 			if err := os.Symlink(fname, symlink); err != nil {
-				fmt.Fprintf(os.Stderr, "could not create symlink %q->%q (ignored): %v\n", symlink, fname, err)
+				f.backend.reportError(ErrorSymlink, f, symlink, err)
 			}
 
 			if f.backend.opts.LogLinkDir != "" {
 				lsymlink := filepath.Join(f.backend.opts.LogLinkDir, link)
 				if err := os.Remove(lsymlink); err != nil && !errors.Is(err, os.ErrNotExist) {
-					fmt.Fprintf(os.Stderr, "could not remove symlink %q (ignored): %v\n", lsymlink, err)
+					f.backend.reportError(ErrorSymlink, f, lsymlink, err)
 				}
-				if err := os.Symlink(fname, lsymlink); err != nil {
-					fmt.Fprintf(os.Stderr, "could not create symlink %q->%q (ignroed): %v\n", lsymlink, fname, err)
+				// Link must use the full path because log file is in a different
+				// directory.
+				target := fpath
+				if abs, err := filepath.Abs(fpath); err == nil {
+					target = abs
+				}
+				if err := os.Symlink(target, lsymlink); err != nil {
+					f.backend.reportError(ErrorSymlink, f, lsymlink, err)
 				}
 			}
 		}
@@ -247,10 +361,25 @@ func (f *levelFile) createFile(t time.Time) (fp *os.File, filename string, err e
 	return nil, "", fmt.Errorf("log: cannot create log: %w", lastErr)
 }
 
+// newFileTime returns the time for the name of a new log file created at the
+// input time. Templates without the {seq} field repeat the names of the log
+// files rotated in the same second, so the time is moved past the current log
+// file's time instead, which keeps the names unique and ordered.
+func (f *levelFile) newFileTime(now time.Time) time.Time {
+	if f.file == nil || slices.Contains(f.backend.fileNameTemplate, "{seq}") {
+		return now
+	}
+	if next := f.nameTime.Add(time.Second); now.Before(next) {
+		return next
+	}
+	return now
+}
+
 func (f *levelFile) rotateFile(now time.Time) error {
 	var err error
 	pn := "<none>"
-	file, fpath, err := f.createFile(now)
+	disk := f.backend.disk.Load()
+	file, fpath, err := f.createFile(f.newFileTime(now))
 	if err != nil {
 		return err
 	}
@@ -260,12 +389,25 @@ func (f *levelFile) rotateFile(now time.Time) error {
 		// so save its name for use in the header of the next file.
 		pn = f.file.Name()
 		if err := f.file.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "could not close file (ignored): %v", err)
+			f.backend.reportError(ErrorClose, f, pn, err)
+		}
+		f.stats.rotations.Add(1)
+		if f.backend.opts.LogFileCompress && pn != fpath {
+			f.backend.compressFile(f, pn)
 		}
 	}
 
 	f.file = file
+	f.disk = disk
 	f.fpaths = append(f.fpaths, fpath)
+	if ts, err := f.fileTime(filepath.Base(fpath)); err == nil {
+		f.nameTime = ts
+	}
+	if f.backend.opts.LogFileRotateInterval > 0 {
+		_, f.rotateAt = f.backend.opts.rotationWindow(now)
+	}
+
+	f.backend.removeOldFiles(now)
 
 	if f.backend.opts.LogFileHeader {
 		if f.nbytes == 0 {
@@ -278,6 +420,7 @@ func (f *levelFile) rotateFile(now time.Time) error {
 			fmt.Fprintf(&buf, "Log line format: [IWEF]mmdd hh:mm:ss.uuuuuu threadid file:line] msg\n")
 			n, err := f.file.Write(buf.Bytes())
 			f.nbytes += uint64(n)
+			f.stats.bytes.Add(uint64(n))
 			if err != nil {
 				return err
 			}
@@ -289,6 +432,7 @@ func (f *levelFile) rotateFile(now time.Time) error {
 			fmt.Fprintf(&buf, "Binary: Built with %s %s for %s/%s\n", runtime.Compiler, runtime.Version(), runtime.GOOS, runtime.GOARCH)
 			n, err := f.file.Write(buf.Bytes())
 			f.nbytes += uint64(n)
+			f.stats.bytes.Add(uint64(n))
 			if err != nil {
 				return err
 			}
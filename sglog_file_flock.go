@@ -0,0 +1,36 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package sglog
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile acquires an exclusive advisory lock on the open file without
+// blocking. Returns false if the lock is held by another open file, which is
+// typically another process writing to the same log file. Lock is released
+// when the file is closed.
+func lockFile(fp *os.File) (bool, error) {
+	err := syscall.Flock(int(fp.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return false, err
+}
+
+// isLocked returns true if the file is locked by another open file.
+func isLocked(fpath string) bool {
+	fp, err := os.Open(fpath)
+	if err != nil {
+		return false
+	}
+	defer fp.Close()
+
+	locked, err := lockFile(fp)
+	return err == nil && !locked
+}
@@ -0,0 +1,54 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package sglog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockedFileIsNotReused(t *testing.T) {
+	dir := t.TempDir()
+	opts := &Options{
+		Name:    "flock",
+		LogDirs: []string{dir},
+	}
+
+	// Create and lock an existing log file as if another process owns it.
+	f := newTestLevelFile(opts, slog.LevelInfo)
+	owned := filepath.Join(dir, f.fileName(time.Now().Add(-time.Minute)))
+	fp, err := os.OpenFile(owned, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	if locked, err := lockFile(fp); err != nil || !locked {
+		t.Fatalf("could not lock file: %v", err)
+	}
+
+	backend := NewBackend(opts)
+	slog.New(backend.Handler()).Info("info message")
+	current := backend.fileMap[slog.LevelInfo].file.Name()
+	backend.Close()
+
+	if current == owned {
+		t.Fatalf("log file locked by another owner must not be reused")
+	}
+	if fi, err := os.Stat(owned); err != nil || fi.Size() != 0 {
+		t.Fatalf("locked log file must not be written: %v", err)
+	}
+
+	// Log file can be reused once the lock is released.
+	fp.Close()
+	backend = NewBackend(opts)
+	slog.New(backend.Handler()).Info("info message")
+	reused := backend.fileMap[slog.LevelInfo].file.Name()
+	backend.Close()
+
+	if reused != current {
+		t.Fatalf("unlocked log file %q must be reused, got %q", current, reused)
+	}
+}
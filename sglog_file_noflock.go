@@ -0,0 +1,16 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package sglog
+
+import "os"
+
+// lockFile is a no-op on platforms without flock support, so log files are
+// always considered unlocked.
+func lockFile(fp *os.File) (bool, error) {
+	return true, nil
+}
+
+// isLocked always returns false on platforms without flock support.
+func isLocked(fpath string) bool {
+	return false
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || dragonfly || freebsd)
+
+package sglog
+
+import "errors"
+
+// freeSpace is not supported on platforms without statfs, so the free disk
+// space checks are disabled.
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -2,6 +2,7 @@ package sglog
 
 import (
 	"log"
+	"path/filepath"
 	"testing"
 
 	"log/slog"
@@ -12,6 +13,7 @@ func TestBasic(t *testing.T) {
 
 	backend := NewBackend(&Options{
 		LogFileMaxSize: 5 * 1024 * 1024,
+		LogDirs:        []string{t.TempDir()},
 	})
 	defer backend.Close()
 
@@ -41,7 +43,7 @@ func TestLogFileRotation(t *testing.T) {
 	backend := NewBackend(&Options{
 		Name:           "testing",
 		LogFileMaxSize: 1024 * 1024,
-		LogDirs:        []string{"."},
+		LogDirs:        []string{t.TempDir()},
 	})
 	defer backend.Close()
 
@@ -66,3 +68,37 @@ func TestLogFileRotation(t *testing.T) {
 		log.Printf("hello world [iteration=%d]", i)
 	}
 }
+
+func TestLogFileRotationSameSecond(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewBackend(&Options{
+		Name:           "rotate",
+		LogFileMaxSize: 100,
+		LogDirs:        []string{dir},
+	})
+	logger := slog.New(backend.Handler())
+
+	// Log files are rotated many times within a second with the default
+	// template, which has no {seq} field.
+	for i := 0; i < 10; i++ {
+		logger.Info("message with a long enough text to fill the log file", "iteration", i)
+	}
+	backend.Close()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "rotate.*.log.INFO.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 10 {
+		t.Fatalf("want 10 log files, got %q", matches)
+	}
+	for _, path := range matches {
+		lines := readLines(t, path)
+		if len(lines) != 1 {
+			t.Errorf("want one log message in %q, got %q", path, lines)
+		}
+	}
+	if n := backend.Stats().Levels["INFO"].Rotations; n != 9 {
+		t.Errorf("want 9 rotations, got %d", n)
+	}
+}
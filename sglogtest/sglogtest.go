@@ -0,0 +1,331 @@
+// Package sglogtest provides helpers to capture and assert on the log output
+// in tests.
+//
+// Recorder is a slog.Handler that keeps the log records, along with their
+// log lines in the sglog text format, in memory. Records can be searched with
+// filters on their level, message, attributes and vmodule, and the log lines
+// can also be written to the test log with testing.T.Log.
+//
+//	rec := sglogtest.New(t)
+//	logger := slog.New(rec)
+//	logger.Warn("retrying", "attempt", 2)
+//	rec.AssertLogged(t, sglogtest.Level(slog.LevelWarn), sglogtest.Attr("attempt", 2))
+//
+// NewBackend creates a regular sglog backend that writes its log files in a
+// temporary directory of the test, which is removed after the test.
+package sglogtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/visvasity/sglog"
+)
+
+// Entry is a log record captured by the Recorder.
+type Entry struct {
+	// Record holds the log record with the attributes from WithAttrs and
+	// WithGroup included, where groups are represented as slog.Group
+	// attributes.
+	Record slog.Record
+
+	// Line is the log line in the sglog text format, without the trailing
+	// newline.
+	Line string
+}
+
+// Attr returns the value of the attribute with the key. Attributes in groups
+// are looked up with the keys prefixed with the group names separated by dots,
+// like "request.id".
+func (e *Entry) Attr(key string) (slog.Value, bool) {
+	var value slog.Value
+	var found bool
+	e.Record.Attrs(func(a slog.Attr) bool {
+		value, found = findAttr(a, key)
+		return !found
+	})
+	return value, found
+}
+
+// VModule returns the name of the vmodule of the log record, if any.
+func (e *Entry) VModule() (string, bool) {
+	var name string
+	var found bool
+	e.Record.Attrs(func(a slog.Attr) bool {
+		if _, ok := sglog.VModuleLevel(a); ok {
+			name, found = a.Value.Resolve().String(), true
+		}
+		return !found
+	})
+	return name, found
+}
+
+func findAttr(a slog.Attr, key string) (slog.Value, bool) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Key == key {
+			return a.Value, true
+		}
+		return slog.Value{}, false
+	}
+	if a.Key != "" {
+		rest, ok := strings.CutPrefix(key, a.Key+".")
+		if !ok {
+			return slog.Value{}, false
+		}
+		key = rest
+	}
+	for _, ga := range a.Value.Group() {
+		if v, ok := findAttr(ga, key); ok {
+			return v, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// recorderState is shared by all handlers derived from a Recorder.
+type recorderState struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Recorder is a slog.Handler that captures the log records in memory.
+type Recorder struct {
+	state *recorderState
+
+	t     testing.TB
+	level slog.Leveler
+
+	goas []sglog.GroupOrAttrs
+}
+
+// New returns a Recorder that captures the log records at all levels. If t is
+// non-nil, log lines are also written to the test log with t.Log.
+//
+// The test log reports the location of its t.Log calls, which is always in
+// the Recorder, because the slog package's frames between the log statement
+// and the Recorder cannot be marked as test helpers. So the test log lines
+// start with the full source file path and line of the log statement, taken
+// from the record's program counter, followed by the sglog text format line.
+func New(t testing.TB) *Recorder {
+	return &Recorder{state: new(recorderState), t: t}
+}
+
+// WithLevel returns a Recorder that shares the captured records with r, but
+// only captures the log records at or above the level. Like with the sglog
+// backend, vmodule attributes with lower log levels enable the log records at
+// their levels.
+func (r *Recorder) WithLevel(level slog.Leveler) *Recorder {
+	r2 := *r
+	r2.level = level
+	return &r2
+}
+
+// Enabled implements the Enabled method for slog.Handler interface.
+func (r *Recorder) Enabled(ctx context.Context, level slog.Level) bool {
+	if r.level == nil {
+		return true
+	}
+	min := r.level.Level()
+	if l, ok := sglog.ContextLevel(ctx); ok && l < min {
+		min = l
+	}
+	for _, goa := range r.goas {
+		for _, a := range goa.Attrs {
+			if l, ok := sglog.VModuleLevel(a); ok && l < min {
+				min = l
+			}
+		}
+	}
+	return level >= min
+}
+
+// WithAttrs implements the WithAttrs method for slog.Handler interface.
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return r
+	}
+	return r.with(sglog.GroupOrAttrs{Attrs: attrs})
+}
+
+// WithGroup implements the WithGroup method for slog.Handler interface.
+func (r *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return r
+	}
+	return r.with(sglog.GroupOrAttrs{Group: name})
+}
+
+func (r *Recorder) with(goa sglog.GroupOrAttrs) *Recorder {
+	r2 := *r
+	r2.goas = append(slices.Clip(r.goas), goa)
+	return &r2
+}
+
+// Handle implements the Handle method for slog.Handler interface.
+func (r *Recorder) Handle(ctx context.Context, rec slog.Record) error {
+	if !r.Enabled(ctx, rec.Level) {
+		return nil
+	}
+
+	rec = sglog.BuildRecord(rec, r.goas)
+	var buf bytes.Buffer
+	sglog.TextFormatter{}.Format(&buf, rec)
+	line := strings.TrimSuffix(buf.String(), "\n")
+
+	r.state.mu.Lock()
+	r.state.entries = append(r.state.entries, Entry{Record: rec, Line: line})
+	r.state.mu.Unlock()
+
+	if r.t != nil {
+		r.t.Log(sourcePrefix(rec.PC) + line)
+	}
+	return nil
+}
+
+// sourcePrefix returns the "file:line: " prefix for the test log with the
+// source location of the program counter, or empty if it is not known.
+func sourcePrefix(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d: ", frame.File, frame.Line)
+}
+
+// Entries returns the captured log records in the order they were logged.
+func (r *Recorder) Entries() []Entry {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	return slices.Clone(r.state.entries)
+}
+
+// Lines returns the log lines of the captured log records.
+func (r *Recorder) Lines() []string {
+	var lines []string
+	for _, e := range r.Entries() {
+		lines = append(lines, e.Line)
+	}
+	return lines
+}
+
+// Reset discards the captured log records.
+func (r *Recorder) Reset() {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	r.state.entries = nil
+}
+
+// Filter selects the captured log records.
+type Filter func(e *Entry) bool
+
+// Level selects the log records at the level.
+func Level(level slog.Level) Filter {
+	return func(e *Entry) bool { return e.Record.Level == level }
+}
+
+// Message selects the log records with messages that contain the substring.
+func Message(substr string) Filter {
+	return func(e *Entry) bool { return strings.Contains(e.Record.Message, substr) }
+}
+
+// Attr selects the log records with the attribute and value. Attributes in
+// groups are selected with keys prefixed with the group names separated by
+// dots, like "request.id".
+func Attr(key string, value any) Filter {
+	want := slog.AnyValue(value).Resolve()
+	return func(e *Entry) bool {
+		v, ok := e.Attr(key)
+		return ok && v.Equal(want)
+	}
+}
+
+// HasAttr selects the log records with the attribute, regardless of its value.
+func HasAttr(key string) Filter {
+	return func(e *Entry) bool {
+		_, ok := e.Attr(key)
+		return ok
+	}
+}
+
+// VModule selects the log records with the vmodule.
+func VModule(name string) Filter {
+	return func(e *Entry) bool {
+		n, ok := e.VModule()
+		return ok && n == name
+	}
+}
+
+// Find returns the captured log records selected by all filters.
+func (r *Recorder) Find(filters ...Filter) []Entry {
+	var entries []Entry
+	for _, e := range r.Entries() {
+		if matchAll(&e, filters) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func matchAll(e *Entry, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertLogged reports a test error if none of the captured log records is
+// selected by all filters. Returns the first selected log record, if any.
+func (r *Recorder) AssertLogged(t testing.TB, filters ...Filter) *Entry {
+	t.Helper()
+	entries := r.Find(filters...)
+	if len(entries) == 0 {
+		t.Errorf("no matching log message in:\n%s", r.dump())
+		return nil
+	}
+	return &entries[0]
+}
+
+// AssertNotLogged reports a test error if any of the captured log records is
+// selected by all filters.
+func (r *Recorder) AssertNotLogged(t testing.TB, filters ...Filter) {
+	t.Helper()
+	for _, e := range r.Find(filters...) {
+		t.Errorf("unexpected log message: %s", e.Line)
+	}
+}
+
+// dump returns the captured log lines for the error messages.
+func (r *Recorder) dump() string {
+	lines := r.Lines()
+	if len(lines) == 0 {
+		return "\t<none>"
+	}
+	return fmt.Sprintf("\t%s", strings.Join(lines, "\n\t"))
+}
+
+// NewBackend creates an sglog backend with its log files in a temporary
+// directory of the test, which is closed when the test finishes. Options can
+// be nil.
+func NewBackend(t testing.TB, opts *sglog.Options) *sglog.Backend {
+	t.Helper()
+	if opts == nil {
+		opts = new(sglog.Options)
+	}
+	opts.LogDirs = []string{t.TempDir()}
+	backend := sglog.NewBackend(opts)
+	t.Cleanup(backend.Close)
+	return backend
+}
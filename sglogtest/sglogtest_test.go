@@ -0,0 +1,88 @@
+package sglogtest
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/visvasity/sglog"
+)
+
+// fakeT records the test errors instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors []string
+	logs   []string
+}
+
+func (t *fakeT) Log(args ...any) {
+	t.logs = append(t.logs, fmt.Sprint(args...))
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	rec := New(t)
+	storage := sglog.VModule("sglogtest-storage", slog.LevelDebug)
+
+	logger := slog.New(rec.WithLevel(slog.LevelInfo))
+	logger.Debug("dropped debug message")
+	logger.With(storage).Debug("storage debug message", "disk", "sda")
+	logger.WithGroup("req").Info("request done", "id", 42, slog.Group("user", "name", "alice"))
+	logger.Warn("warning message")
+
+	if n := len(rec.Entries()); n != 3 {
+		t.Fatalf("want 3 records, got %d: %q", n, rec.Lines())
+	}
+
+	rec.AssertLogged(t, Level(slog.LevelDebug), VModule("sglogtest-storage"), Attr("disk", "sda"))
+	rec.AssertLogged(t, Message("request"), Attr("req.id", 42), Attr("req.user.name", "alice"))
+	rec.AssertNotLogged(t, Message("dropped"))
+
+	e := rec.AssertLogged(t, Level(slog.LevelWarn))
+	if e == nil || !strings.HasPrefix(e.Line, "W") || !strings.Contains(e.Line, "sglogtest_test.go:") || !strings.HasSuffix(e.Line, "] warning message") {
+		t.Fatalf("unexpected log line %v", e)
+	}
+
+	ft := new(fakeT)
+	rec.AssertLogged(ft, Message("missing"))
+	rec.AssertNotLogged(ft, HasAttr("req.id"))
+	if len(ft.errors) != 2 {
+		t.Fatalf("want 2 assertion failures, got %q", ft.errors)
+	}
+
+	rec.Reset()
+	if n := len(rec.Entries()); n != 0 {
+		t.Fatalf("want no records after reset, got %d", n)
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	backend := NewBackend(t, &sglog.Options{Name: "sglogtest"})
+	slog.New(backend.Handler()).Info("info message")
+
+	path := backend.LogFiles()[slog.LevelInfo]
+	// All temporary directories of a test share the same parent.
+	if filepath.Dir(filepath.Dir(path)) != filepath.Dir(t.TempDir()) {
+		t.Fatalf("want log file in the test's temporary directory, got %q", path)
+	}
+}
+
+func TestRecorderTestLog(t *testing.T) {
+	ft := new(fakeT)
+	logger := slog.New(New(ft))
+	_, file, line, _ := runtime.Caller(0)
+	logger.Info("test log message", "key", "value")
+
+	want := fmt.Sprintf("%s:%d: I", file, line+1)
+	if len(ft.logs) != 1 || !strings.HasPrefix(ft.logs[0], want) || !strings.HasSuffix(ft.logs[0], `] test log message key="value"`) {
+		t.Fatalf("want test log line with prefix %q, got %q", want, ft.logs)
+	}
+}
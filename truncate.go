@@ -0,0 +1,119 @@
+package sglog
+
+import (
+	"bytes"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// truncateSuffix marks the shortened message and attribute values.
+const truncateSuffix = "..."
+
+// lineLen returns the length of the formatted log line, without the trailing
+// newline.
+func lineLen(buf *bytes.Buffer) int {
+	if b := buf.Bytes(); len(b) > 0 && b[len(b)-1] == '\n' {
+		return len(b) - 1
+	}
+	return buf.Len()
+}
+
+// formatShort formats the log record into at most max bytes, excluding the
+// trailing newline. It finds the longest limit on the message and attribute
+// value lengths that fits. If the record doesn't fit even with empty values,
+// attributes are dropped and, as the last resort, the formatted bytes are cut.
+func (v *Backend) formatShort(buf *bytes.Buffer, r slog.Record, max int) {
+	fits := func(r slog.Record) bool {
+		buf.Reset()
+		v.opts.Formatter.Format(buf, r)
+		return lineLen(buf) <= max
+	}
+
+	best := -1
+	for lo, hi := 0, longestValue(r); lo <= hi; {
+		mid := (lo + hi) / 2
+		if fits(shortenRecord(r, mid)) {
+			best, lo = mid, mid+1
+		} else {
+			hi = mid - 1
+		}
+	}
+	if best >= 0 && fits(shortenRecord(r, best)) {
+		return
+	}
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	for limit := len(r.Message); limit >= 0; limit /= 2 {
+		nr.Message = shortenString(r.Message, limit)
+		if fits(nr) || limit == 0 {
+			break
+		}
+	}
+	if lineLen(buf) > max {
+		buf.Truncate(max)
+	}
+}
+
+// longestValue returns the length of the longest string among the message and
+// the attribute values of the log record.
+func longestValue(r slog.Record) int {
+	n := len(r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		n = max(n, longestAttrValue(a))
+		return true
+	})
+	return n
+}
+
+func longestAttrValue(a slog.Attr) int {
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return len(v.String())
+	}
+	n := 0
+	for _, ga := range v.Group() {
+		n = max(n, longestAttrValue(ga))
+	}
+	return n
+}
+
+// shortenRecord returns a copy of the log record with the message and the
+// attribute values longer than limit bytes shortened. Non-string values are
+// replaced with their shortened string forms.
+func shortenRecord(r slog.Record, limit int) slog.Record {
+	nr := slog.NewRecord(r.Time, r.Level, shortenString(r.Message, limit), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(shortenAttr(a, limit))
+		return true
+	})
+	return nr
+}
+
+func shortenAttr(a slog.Attr, limit int) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		gattrs := a.Value.Group()
+		nattrs := make([]slog.Attr, len(gattrs))
+		for i, ga := range gattrs {
+			nattrs[i] = shortenAttr(ga, limit)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(nattrs...)}
+	}
+	if s := a.Value.String(); len(s) > limit {
+		return slog.String(a.Key, shortenString(s, limit))
+	}
+	return a
+}
+
+// shortenString cuts the string to at most limit bytes, at a rune boundary,
+// and marks it with the truncation suffix.
+func shortenString(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	n := max(limit-len(truncateSuffix), 0)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncateSuffix[:min(limit, len(truncateSuffix))]
+}
@@ -1,18 +1,38 @@
 package sglog
 
-import "log/slog"
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
 const vmoduleKey = "vmodule"
 
 type vmoduleValue struct {
 	name slog.Value
 	lvar slog.LevelVar
+
+	// sampling if non-nil overrides the backend's sampling policy for the log
+	// messages with this module.
+	sampling atomic.Pointer[Sampling]
 }
 
 func (v *vmoduleValue) LogValue() slog.Value {
 	return v.name
 }
 
+var (
+	// vmodulesMu protects the vmodules registry.
+	vmodulesMu sync.Mutex
+
+	// vmodules holds all vmodules created with VModule by their names.
+	vmodules = make(map[string]*vmoduleValue)
+)
+
 // VModule creates a module log level control attribute.
 //
 // VModule attributes contain a private log level that can be used to turn
@@ -20,11 +40,27 @@ func (v *vmoduleValue) LogValue() slog.Value {
 //
 // Users can change a module's log level dynamically without effecting other
 // module's log level.
+//
+// Modules are registered by their names in a process-wide registry, so that
+// their log levels can also be changed by name.
+//
+// The level is only the initial log level of a new module. If a module with
+// the same name is already registered, its attribute is returned and the input
+// level is ignored, so that all users of a module name share the same log
+// level. Use SetVModuleLevel or SetVModules to change the level of an existing
+// module.
 func VModule(name string, level slog.Level) slog.Attr {
+	vmodulesMu.Lock()
+	defer vmodulesMu.Unlock()
+
+	if value, ok := vmodules[name]; ok {
+		return slog.Any(vmoduleKey, value)
+	}
 	value := &vmoduleValue{
 		name: slog.StringValue(name),
 	}
 	value.lvar.Set(level)
+	vmodules[name] = value
 	return slog.Any(vmoduleKey, value)
 }
 
@@ -54,3 +90,170 @@ func VModuleLevel(a slog.Attr) (slog.Level, bool) {
 	}
 	return value.lvar.Level(), true
 }
+
+// SetVModuleSampling sets the sampling policy for log messages with a vmodule
+// attribute, which overrides the backend's sampling policy. Nil policy
+// removes the override. Returns false if input attribute is not a vmodule
+// attribute.
+func SetVModuleSampling(a slog.Attr, s *Sampling) bool {
+	if a.Key != vmoduleKey {
+		return false
+	}
+	value, ok := a.Value.Any().(*vmoduleValue)
+	if !ok {
+		return false
+	}
+	value.sampling.Store(s)
+	return true
+}
+
+// LookupVModule returns the vmodule attribute with the input name. Returns
+// false if no such module is registered.
+func LookupVModule(name string) (slog.Attr, bool) {
+	vmodulesMu.Lock()
+	defer vmodulesMu.Unlock()
+
+	value, ok := vmodules[name]
+	if !ok {
+		return slog.Attr{}, false
+	}
+	return slog.Any(vmoduleKey, value), true
+}
+
+// VModuleInfo describes a registered vmodule.
+type VModuleInfo struct {
+	Name  string
+	Level slog.Level
+}
+
+// VModules returns all registered vmodules and their current log levels,
+// sorted by their names.
+func VModules() []VModuleInfo {
+	vmodulesMu.Lock()
+	defer vmodulesMu.Unlock()
+
+	infos := make([]VModuleInfo, 0, len(vmodules))
+	for name, value := range vmodules {
+		infos = append(infos, VModuleInfo{Name: name, Level: value.lvar.Level()})
+	}
+	slices.SortFunc(infos, func(a, b VModuleInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return infos
+}
+
+// SetVModules changes the log level of all registered vmodules with names
+// matching the glob pattern (as in path.Match). Returns the number of modules
+// changed.
+func SetVModules(pattern string, level slog.Level) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+
+	vmodulesMu.Lock()
+	defer vmodulesMu.Unlock()
+
+	n := 0
+	for name, value := range vmodules {
+		if ok, _ := path.Match(pattern, name); ok {
+			value.lvar.Set(level)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ApplyVModuleSpec changes the log levels of registered vmodules from a spec,
+// like "network=debug,storage=error", which is a comma separated list of
+// pattern=level pairs. Patterns are glob patterns for the module names and
+// levels are log level names or verbosity numbers as accepted by ParseLevel.
+// Pairs are applied in order, so later pairs override the earlier ones. Spec
+// is validated completely before any log level is changed.
+func ApplyVModuleSpec(spec string) error {
+	items, err := parseVModuleSpec(spec)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := SetVModules(item.pattern, item.level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type vmoduleItem struct {
+	pattern string
+	level   slog.Level
+}
+
+// parseVModuleSpec parses and validates a vmodule spec.
+func parseVModuleSpec(spec string) ([]vmoduleItem, error) {
+	var items []vmoduleItem
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(s, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("vmodule item %q is not in pattern=level form", s)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("vmodule item %q has invalid pattern: %w", s, err)
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("vmodule item %q has invalid level: %w", s, err)
+		}
+		items = append(items, vmoduleItem{pattern: pattern, level: level})
+	}
+	return items, nil
+}
+
+// VModuleSpec returns the current log levels of all registered vmodules as a
+// spec that can be used with ApplyVModuleSpec. Modules with names that cannot
+// be used in a spec, because they have commas, equal signs, glob pattern
+// characters or surrounding spaces, are left out; use VModules and
+// SetVModuleLevel to save and restore their levels.
+func VModuleSpec() string {
+	var items []string
+	for _, info := range VModules() {
+		if !specName(info.Name) {
+			continue
+		}
+		items = append(items, fmt.Sprintf("%s=%s", info.Name, info.Level))
+	}
+	return strings.Join(items, ",")
+}
+
+// specName returns true if the vmodule name matches only itself when used as
+// a pattern in a vmodule spec.
+func specName(name string) bool {
+	return name != "" && name == strings.TrimSpace(name) && !strings.ContainsAny(name, `,=*?[\`)
+}
+
+// vmoduleName returns the name of the first vmodule attribute in the log
+// record, including the attributes in groups.
+func vmoduleName(r slog.Record) string {
+	var name string
+	r.Attrs(func(a slog.Attr) bool {
+		name = attrVModuleName(a)
+		return name == ""
+	})
+	return name
+}
+
+func attrVModuleName(a slog.Attr) string {
+	if _, ok := VModuleLevel(a); ok {
+		return a.Value.Resolve().String()
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			if name := attrVModuleName(ga); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
@@ -2,6 +2,7 @@ package sglog
 
 import (
 	"log"
+	"strings"
 	"testing"
 
 	"log/slog"
@@ -54,3 +55,61 @@ func TestVModuleLogging(t *testing.T) {
 	slog.With(network).Warn("this is a network module's second warn message ")
 	slog.With(network).Error("this is a network module's second error message ")
 }
+
+func TestVModuleRegistry(t *testing.T) {
+	disk := VModule("registry-disk", slog.LevelInfo)
+	net := VModule("registry-net", slog.LevelInfo)
+	VModule("registry-cpu", slog.LevelWarn)
+
+	if again := VModule("registry-disk", slog.LevelError); !again.Equal(disk) {
+		t.Fatalf("vmodule with the same name must return the existing attribute")
+	}
+	if level, _ := VModuleLevel(disk); level != slog.LevelInfo {
+		t.Fatalf("vmodule with the same name must not change the level, got %v", level)
+	}
+	if a, ok := LookupVModule("registry-net"); !ok || !a.Equal(net) {
+		t.Fatalf("could not lookup registered vmodule")
+	}
+	if _, ok := LookupVModule("registry-missing"); ok {
+		t.Fatalf("unregistered vmodule must not be found")
+	}
+
+	if n, err := SetVModules("registry-*", slog.LevelError); err != nil || n != 3 {
+		t.Fatalf("want 3 modules changed, got %d: %v", n, err)
+	}
+	if err := ApplyVModuleSpec("registry-*=info, registry-net=debug,registry-cpu=2"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]slog.Level{
+		"registry-cpu":  slog.LevelInfo - 2,
+		"registry-disk": slog.LevelInfo,
+		"registry-net":  slog.LevelDebug,
+	}
+	for _, info := range VModules() {
+		if level, ok := want[info.Name]; ok && level != info.Level {
+			t.Errorf("module %s: got level %v, want %v", info.Name, info.Level, level)
+		}
+	}
+
+	if err := ApplyVModuleSpec("registry-disk=error,registry-net=bad"); err == nil {
+		t.Fatalf("invalid spec must fail")
+	}
+	if level, _ := VModuleLevel(disk); level != slog.LevelInfo {
+		t.Fatalf("invalid spec must not change any module level")
+	}
+
+	// Modules that cannot be named in a spec are left out of it.
+	VModule("odd-registry,name=*", slog.LevelInfo)
+	spec := VModuleSpec()
+	if strings.Contains(spec, "odd-registry") {
+		t.Fatalf("vmodule spec %q must not have the module with special characters", spec)
+	}
+	SetVModules("registry-*", slog.LevelError)
+	if err := ApplyVModuleSpec(spec); err != nil {
+		t.Fatal(err)
+	}
+	if level, _ := VModuleLevel(net); level != slog.LevelDebug {
+		t.Fatalf("vmodule spec must restore the levels, got %v", level)
+	}
+}